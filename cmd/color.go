@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	colorSettings = core.DefaultColorSettings
+	colorOutput   string
+	watch         time.Duration
+)
+
+var colorOutputs = []string{"number", "gammastep", "hyprsunset", "wlsunset"}
+
+var colorCmd = &cobra.Command{
+	Use:   "color --city CITY",
+	Short: "Print the screen color temperature for the sun's elevation.",
+	Long: `Print the color temperature and brightness to set your screen to, fading from
+the day settings to the night settings as the sun goes down, like redshift.
+
+Use --output to print a command that sets it instead, and --watch to keep
+printing it as it changes:
+
+  sundial color --city Denver --output gammastep --watch 1m | sh -s
+
+hyprsunset and wlsunset keep running once started, so --watch can't be used
+with them: start them once instead. wlsunset follows the sun itself, so for it
+the command starts wlsunset with the same settings and location.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if colorSettings.HighElevation <= colorSettings.LowElevation {
+			return fmt.Errorf("--elevation-high must be above --elevation-low, got %g and %g", colorSettings.HighElevation, colorSettings.LowElevation)
+		}
+		// Piping these to sh -s would wait on the first one forever
+		if watch > 0 && (colorOutput == "hyprsunset" || colorOutput == "wlsunset") {
+			return fmt.Errorf("--watch can't be used with --output %s, which keeps running; run it once instead", colorOutput)
+		}
+		for _, o := range colorOutputs {
+			if colorOutput == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("--output must be one of number, gammastep, hyprsunset, or wlsunset, not '%s'", colorOutput)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		if watch <= 0 {
+			fmt.Println(colorLine(city, resolveTime(city.Location())))
+			return
+		}
+		if givenTime != "" {
+			fmt.Fprintln(os.Stderr, "Error: --watch always uses the current time, so can't be used with --time")
+			os.Exit(1)
+		}
+		last := ""
+		for {
+			if line := colorLine(city, time.Now().In(city.Location())); line != last {
+				fmt.Println(line)
+				last = line
+			}
+			time.Sleep(watch)
+		}
+	},
+}
+
+// colorLine returns the screen color at t with the --format template, or
+// else the color or the command that sets it, as --output says.
+func colorLine(city *core.CityInfo, t time.Time) string {
+	color := city.ScreenColor(t, colorSettings)
+	if format != "" {
+		return formatResult(color)
+	}
+	switch colorOutput {
+	case "gammastep":
+		return fmt.Sprintf("gammastep -P -O %d -b %.2f", color.Temperature, color.Brightness)
+	case "hyprsunset":
+		return fmt.Sprintf("hyprsunset -t %d", color.Temperature)
+	case "wlsunset":
+		return fmt.Sprintf("wlsunset -l %.2f -L %.2f -t %d -T %d",
+			city.Latitude, city.Longitude, colorSettings.NightTemperature, colorSettings.DayTemperature)
+	}
+	return color.String()
+}
+
+func init() {
+	colorCmd.Flags().IntVar(&colorSettings.DayTemperature, "temp-day", colorSettings.DayTemperature, "Color temperature by day, in Kelvin.")
+	colorCmd.Flags().IntVar(&colorSettings.NightTemperature, "temp-night", colorSettings.NightTemperature, "Color temperature by night, in Kelvin.")
+	colorCmd.Flags().Float64Var(&colorSettings.DayBrightness, "brightness-day", colorSettings.DayBrightness, "Brightness by day, from 0 to 1.")
+	colorCmd.Flags().Float64Var(&colorSettings.NightBrightness, "brightness-night", colorSettings.NightBrightness, "Brightness by night, from 0 to 1.")
+	colorCmd.Flags().Float64Var(&colorSettings.HighElevation, "elevation-high", colorSettings.HighElevation, "Sun elevation in degrees above which it's day.")
+	colorCmd.Flags().Float64Var(&colorSettings.LowElevation, "elevation-low", colorSettings.LowElevation, "Sun elevation in degrees below which it's night.")
+	colorCmd.Flags().StringVar(&colorOutput, "output", "number", "What to print: number, or a command for gammastep, hyprsunset, or wlsunset.")
+	colorCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(colorOutputs, cobra.ShellCompDirectiveNoFileComp))
+	colorCmd.Flags().DurationVar(&watch, "watch", 0, "Keep running, and print again whenever the output changes, checking this often, e.g. 1m.")
+	colorCmd.Flags().StringVar(&format, "format", "", "Go template to print the color with, e.g. '{{.Temperature}}'.\nFields: .Temperature .Brightness .Elevation .Fraction .At")
+	addPlaceFlags(colorCmd)
+	addTimeFlag(colorCmd)
+	rootCmd.AddCommand(colorCmd)
+}
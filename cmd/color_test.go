@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+)
+
+func TestColorLineUsesFormat(t *testing.T) {
+	savedFormat, savedOutput := format, colorOutput
+	defer func() { format, colorOutput = savedFormat, savedOutput }()
+
+	city, err := core.FindCity("Denver", "US", "CO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	noon := time.Date(2026, 6, 21, 12, 0, 0, 0, time.FixedZone("MDT", -6*3600))
+	format, colorOutput = "{{.Temperature}}K", "gammastep"
+	if got := colorLine(city, noon); got != "6500K" {
+		t.Errorf("colorLine with --format = %q, want 6500K", got)
+	}
+}
+
+func TestColorWatchRejectsDaemons(t *testing.T) {
+	savedWatch, savedOutput := watch, colorOutput
+	defer func() { watch, colorOutput = savedWatch, savedOutput }()
+
+	watch = time.Minute
+	for _, output := range []string{"hyprsunset", "wlsunset"} {
+		colorOutput = output
+		if err := colorCmd.PreRunE(colorCmd, nil); err == nil || !strings.Contains(err.Error(), "--watch") {
+			t.Errorf("--watch with --output %s: %v, want an error about --watch", output, err)
+		}
+	}
+	for _, output := range []string{"number", "gammastep"} {
+		colorOutput = output
+		if err := colorCmd.PreRunE(colorCmd, nil); err != nil {
+			t.Errorf("--watch with --output %s: %v", output, err)
+		}
+	}
+}
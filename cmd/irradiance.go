@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	irradianceFrom  string
+	irradianceTo    string
+	irradianceEvery time.Duration
+	panelTilt       float64
+	panelAzimuth    float64
+	albedo          float64
+	linkeTurbidity  float64
+)
+
+var irradianceCmd = &cobra.Command{
+	Use:   "irradiance --city CITY",
+	Short: "Print clear-sky solar irradiance, or daily totals as CSV.",
+	Long: `Print how much sunlight reaches the ground under a clear sky: global
+horizontal (GHI), direct normal (DNI), and diffuse horizontal (DHI)
+irradiance, and the irradiance on a panel with the given tilt and azimuth.
+It uses the Ineichen and Perez model, so gives what a cloudless day would.
+
+With --from, it prints CSV instead: the daily totals in kWh/m² for each day
+from --from to --to, or with --every, the irradiance in W/m² at each step:
+
+  sundial irradiance --city Denver --tilt 40 --from 2024-01-01 --to 2024-12-31
+  sundial irradiance --city Denver --tilt 40 --from 2024-06-21 --every 1h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		// Face the equator unless told otherwise
+		if !cmd.Flags().Changed("azimuth") && city.Latitude < 0 {
+			panelAzimuth = 0
+		}
+		panel := core.Panel{Tilt: panelTilt, Azimuth: panelAzimuth, Albedo: albedo}
+
+		if irradianceFrom == "" {
+			t := resolveTime(city.Location())
+			printResult(city.ClearSkyIrradiance(t, linkeTurbidity, panel))
+			return
+		}
+
+		now := time.Now().In(city.Location())
+		from, err := core.ParseTime(irradianceFrom, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --from: %s\n", err)
+			os.Exit(1)
+		}
+		to := from
+		if irradianceTo != "" {
+			if to, err = core.ParseTime(irradianceTo, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --to: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		y, m, d := to.Date()
+		end := time.Date(y, m, d+1, 0, 0, 0, 0, to.Location())
+		formatFloat := func(f float64) string {
+			return strconv.FormatFloat(f, 'f', 3, 64)
+		}
+
+		w := csv.NewWriter(os.Stdout)
+		if irradianceEvery > 0 {
+			w.Write([]string{"time", "ghi_w_m2", "dni_w_m2", "dhi_w_m2", "panel_w_m2"})
+			for t := from; t.Before(end); t = t.Add(irradianceEvery) {
+				irr := city.ClearSkyIrradiance(t, linkeTurbidity, panel)
+				w.Write([]string{t.Format(time.RFC3339), formatFloat(irr.GHI), formatFloat(irr.DNI), formatFloat(irr.DHI), formatFloat(irr.Panel)})
+			}
+		} else {
+			w.Write([]string{"date", "ghi_kwh_m2", "dni_kwh_m2", "dhi_kwh_m2", "panel_kwh_m2"})
+			y, m, d := from.Date()
+			for day := time.Date(y, m, d, 12, 0, 0, 0, from.Location()); day.Before(end); day = day.AddDate(0, 0, 1) {
+				ins := city.ClearSkyInsolation(day, linkeTurbidity, panel)
+				w.Write([]string{day.Format("2006-01-02"), formatFloat(ins.GHI), formatFloat(ins.DNI), formatFloat(ins.DHI), formatFloat(ins.Panel)})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	irradianceCmd.Flags().StringVar(&irradianceFrom, "from", "", "First day to print as CSV, in any format --time accepts.")
+	irradianceCmd.Flags().StringVar(&irradianceTo, "to", "", "Last day to print as CSV, in any format --time accepts. Defaults to --from.")
+	irradianceCmd.Flags().DurationVar(&irradianceEvery, "every", 0, "Print the irradiance at every step, e.g. 1h, instead of daily totals.")
+	irradianceCmd.Flags().Float64Var(&panelTilt, "tilt", 0, "Panel tilt in degrees from horizontal.")
+	irradianceCmd.Flags().Float64Var(&panelAzimuth, "azimuth", 180, "Direction the panel faces, in degrees clockwise from north. Defaults to facing the equator.")
+	irradianceCmd.Flags().Float64Var(&albedo, "albedo", core.DefaultAlbedo, "Fraction of light the ground reflects onto the panel: 0.2 for grass, up to 0.8 for snow.")
+	irradianceCmd.Flags().Float64Var(&linkeTurbidity, "linke", core.DefaultLinkeTurbidity, "Linke turbidity of the air: about 2 for very clean air, 3 for rural, 4 to 6 for cities.")
+	irradianceCmd.Flags().StringVar(&format, "format", "", "Go template to print the irradiance with, e.g. '{{printf \"%.0f\" .GHI}}'.\nFields: .GHI .DNI .DHI .Panel .At")
+	addPlaceFlags(irradianceCmd)
+	addTimeFlag(irradianceCmd)
+	rootCmd.AddCommand(irradianceCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLevel  string
+	logFormat string
+)
+
+// setUpLogging sends logs to stderr at the level and in the format the
+// logging flags ask for.
+func setUpLogging(cmd *cobra.Command, args []string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("--log-level must be one of debug, info, warn, or error, not '%s'", logLevel)
+	}
+	if debug {
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch logFormat {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("--log-format must be text or json, not '%s'", logFormat)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setUpLogging(cmd, args); err != nil {
+			return err
+		}
+		return checkCalculationFlags(cmd)
+	}
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Log how the city, time zone, and sun were worked out to stderr. Same as --log-level debug.")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Least severe logs to print to stderr: debug, info, warn, or error.")
+	rootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions([]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format to log in: text, or json for one JSON object per line.")
+	rootCmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
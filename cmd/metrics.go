@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+)
+
+var metricsPlaces []string
+
+// A metric is one gauge /metrics exposes.
+type metric struct {
+	name string
+	help string
+	// samples returns the gauge's values for a place, by extra labels, or
+	// nothing if it has none now.
+	samples func(s *metricsState) []sample
+}
+
+type sample struct {
+	labels string // Besides place, already formatted, e.g. `event="sunset"`
+	value  float64
+}
+
+// metricsState is everything /metrics computes about one place on a scrape.
+type metricsState struct {
+	city     *core.CityInfo
+	at       time.Time
+	period   *core.Period // nil if the sun doesn't rise or set today
+	position core.Position
+}
+
+func single(value float64) []sample {
+	return []sample{{value: value}}
+}
+
+var metrics = []metric{
+	{"sundial_period_fraction", "Fraction of the way through the current day or night, from 0 to 1.", func(s *metricsState) []sample {
+		if s.period == nil {
+			return nil
+		}
+		return single(s.period.Fraction())
+	}},
+	{"sundial_daytime", "1 between sunrise and sunset, 0 otherwise.", func(s *metricsState) []sample {
+		if s.period == nil {
+			return nil
+		}
+		if s.period.Day {
+			return single(1)
+		}
+		return single(0)
+	}},
+	{"sundial_sun_elevation_degrees", "Degrees of the sun above the horizon, corrected for refraction.", func(s *metricsState) []sample {
+		return single(s.position.Elevation)
+	}},
+	{"sundial_sun_azimuth_degrees", "Degrees of the sun clockwise from north.", func(s *metricsState) []sample {
+		return single(s.position.Azimuth)
+	}},
+	{"sundial_day_length_seconds", "Seconds from sunrise to sunset today.", func(s *metricsState) []sample {
+		if s.period == nil {
+			return nil
+		}
+		return single(s.period.Sunset.Sub(s.period.Sunrise).Seconds())
+	}},
+	{"sundial_next_event_seconds", "Seconds until the next sunrise or sunset.", func(s *metricsState) []sample {
+		e, t, ok := s.city.NextEvent(s.at, core.Sunrise, core.Sunset)
+		if !ok {
+			return nil
+		}
+		return []sample{{labels: fmt.Sprintf(`event="%s"`, escapeLabel(e.Name)), value: t.Sub(s.at).Seconds()}}
+	}},
+}
+
+// handleMetrics serves the metrics of every place in cities, in the
+// Prometheus text exposition format. They're computed on each scrape, so
+// they're as fresh as the scrape interval.
+func handleMetrics(cities []*core.CityInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		states := make([]*metricsState, len(cities))
+		for i, city := range cities {
+			at := now.In(city.Location())
+			s := &metricsState{city: city, at: at, position: city.SolarPosition(at)}
+			// Near the poles there may be no period today; leave those gauges out
+			s.period, _ = core.GetPeriod(city, at)
+			states[i] = s
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		out := bufio.NewWriter(w)
+		for _, m := range metrics {
+			fmt.Fprintf(out, "# HELP %s %s\n", m.name, m.help)
+			fmt.Fprintf(out, "# TYPE %s gauge\n", m.name)
+			for _, s := range states {
+				place := fmt.Sprintf("%s, %s, %s", s.city.Name, s.city.CountryCode, s.city.FipsCode)
+				for _, smp := range m.samples(s) {
+					labels := fmt.Sprintf(`place="%s"`, escapeLabel(place))
+					if smp.labels != "" {
+						labels += "," + smp.labels
+					}
+					fmt.Fprintf(out, "%s{%s} %g\n", m.name, labels, smp.value)
+				}
+			}
+		}
+		out.Flush()
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes s for use as a label value.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/riley-martine/sundial/internal/mqtt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mqttBroker          string
+	mqttUsername        string
+	mqttPassword        string
+	mqttPasswordFile    string
+	mqttTopicPrefix     string
+	mqttInterval        time.Duration
+	mqttDiscovery       bool
+	mqttDiscoveryPrefix string
+	mqttOnce            bool
+)
+
+// mqttSensor is one value sundial publishes, and how Home Assistant should
+// show it.
+type mqttSensor struct {
+	key         string
+	name        string
+	unit        string
+	deviceClass string
+	icon        string
+	value       func(s *mqttState) string
+}
+
+// mqttState is everything published on one update.
+type mqttState struct {
+	period   *core.Period
+	position core.Position
+	next     core.Event
+	nextTime time.Time
+	hasNext  bool
+}
+
+var mqttSensors = []mqttSensor{
+	{key: "phase", name: "Phase", icon: "mdi:weather-sunset", value: func(s *mqttState) string {
+		return s.period.Phase()
+	}},
+	{key: "percent", name: "Percent", unit: "%", icon: "mdi:percent", value: func(s *mqttState) string {
+		return fmt.Sprintf("%.1f", s.period.Percent())
+	}},
+	{key: "next_event", name: "Next event", icon: "mdi:calendar-clock", value: func(s *mqttState) string {
+		if !s.hasNext {
+			return "none"
+		}
+		return s.next.Name
+	}},
+	{key: "next_event_time", name: "Next event time", deviceClass: "timestamp", value: func(s *mqttState) string {
+		if !s.hasNext {
+			return "None"
+		}
+		return s.nextTime.Format(time.RFC3339)
+	}},
+	{key: "elevation", name: "Sun elevation", unit: "°", icon: "mdi:angle-acute", value: func(s *mqttState) string {
+		return fmt.Sprintf("%.2f", s.position.Elevation)
+	}},
+	{key: "azimuth", name: "Sun azimuth", unit: "°", icon: "mdi:compass-outline", value: func(s *mqttState) string {
+		return fmt.Sprintf("%.2f", s.position.Azimuth)
+	}},
+}
+
+var mqttCmd = &cobra.Command{
+	Use:   "mqtt --city CITY --broker URL",
+	Short: "Publish the sun's state to an MQTT broker.",
+	Long: `Publish the phase, percent, next sunrise or sunset, and the sun's elevation
+and azimuth to an MQTT broker, as retained topics under --topic-prefix, every
+--interval. With --discovery, it also publishes Home Assistant MQTT discovery
+configs, so the sensors appear there automatically.
+
+To try it against a local mosquitto:
+
+  mosquitto_sub -t 'sundial/#' -v &
+  sundial mqtt --city Denver --broker tcp://localhost:1883 --once`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if mqttInterval <= 0 {
+			return fmt.Errorf("--interval must be positive, not %s", mqttInterval)
+		}
+		if err := resolveMQTTPassword(cmd); err != nil {
+			return err
+		}
+		if mqttPassword != "" && mqttUsername == "" {
+			return fmt.Errorf("a password needs --username")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		id := slug(fmt.Sprintf("%s %s %s", city.Name, city.CountryCode, city.FipsCode))
+		prefix := mqttTopicPrefix
+		if prefix == "" {
+			prefix = "sundial/" + id
+		}
+		statusTopic := prefix + "/status"
+
+		// systemd and docker stop it with SIGTERM, so publish offline then too
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var client *mqtt.Client
+		defer func() {
+			if client == nil {
+				return
+			}
+			// Run from cron, the values stay good until the next run
+			if !mqttOnce {
+				client.Publish(statusTopic, []byte("offline"), true)
+			}
+			client.Close()
+		}()
+		for {
+			err := func() error {
+				if client == nil {
+					var err error
+					client, err = mqtt.Dial(mqttBroker, mqttOptions(id, statusTopic))
+					if err != nil {
+						return err
+					}
+					if mqttDiscovery {
+						if err := publishDiscovery(client, city, id, prefix, statusTopic); err != nil {
+							return err
+						}
+					}
+					if err := client.Publish(statusTopic, []byte("online"), true); err != nil {
+						return err
+					}
+				}
+				return publishState(client, city, prefix)
+			}()
+			if err != nil {
+				if mqttOnce {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+					os.Exit(1)
+				}
+				slog.Warn("publishing failed; retrying", "error", err, "in", mqttInterval)
+				if client != nil {
+					client.Close()
+					client = nil
+				}
+			}
+			if mqttOnce {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(mqttInterval):
+			}
+		}
+	},
+}
+
+// resolveMQTTPassword sets mqttPassword from --password-file or
+// $SUNDIAL_MQTT_PASSWORD if --password wasn't given, since flags show up in
+// ps and shell history.
+func resolveMQTTPassword(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("password") && cmd.Flags().Changed("password-file") {
+		return fmt.Errorf("give --password or --password-file, not both")
+	}
+	if mqttPasswordFile != "" {
+		b, err := os.ReadFile(mqttPasswordFile)
+		if err != nil {
+			return fmt.Errorf("--password-file: %w", err)
+		}
+		// Files written by editors and echo end in a newline
+		mqttPassword = strings.TrimRight(string(b), "\r\n")
+		return nil
+	}
+	if !cmd.Flags().Changed("password") {
+		mqttPassword = os.Getenv("SUNDIAL_MQTT_PASSWORD")
+	}
+	return nil
+}
+
+// mqttOptions returns how to connect to the broker. The broker publishes
+// offline to statusTopic for us if we go away without saying so.
+func mqttOptions(id, statusTopic string) mqtt.Options {
+	return mqtt.Options{
+		ClientID: "sundial-" + id,
+		Username: mqttUsername,
+		Password: mqttPassword,
+		// The client pings between publishes, so this needn't depend on
+		// --interval
+		KeepAlive:   time.Minute,
+		WillTopic:   statusTopic,
+		WillPayload: []byte("offline"),
+	}
+}
+
+// publishState publishes every sensor's value now.
+func publishState(client *mqtt.Client, city *core.CityInfo, prefix string) error {
+	at := time.Now().In(city.Location())
+	period, err := core.GetPeriod(city, at)
+	if err != nil {
+		return err
+	}
+	s := &mqttState{period: period, position: city.SolarPosition(at)}
+	s.next, s.nextTime, s.hasNext = city.NextEvent(at, core.Sunrise, core.Sunset)
+
+	for _, sensor := range mqttSensors {
+		if err := client.Publish(prefix+"/"+sensor.key, []byte(sensor.value(s)), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishDiscovery publishes a Home Assistant discovery config for each
+// sensor, all under one device for the city.
+func publishDiscovery(client *mqtt.Client, city *core.CityInfo, id, prefix, statusTopic string) error {
+	device := map[string]interface{}{
+		"identifiers":  []string{"sundial_" + id},
+		"name":         "Sundial " + city.Name,
+		"manufacturer": "sundial",
+	}
+	for _, sensor := range mqttSensors {
+		config := map[string]interface{}{
+			"name":               sensor.name,
+			"unique_id":          "sundial_" + id + "_" + sensor.key,
+			"state_topic":        prefix + "/" + sensor.key,
+			"availability_topic": statusTopic,
+			"device":             device,
+		}
+		if sensor.unit != "" {
+			config["unit_of_measurement"] = sensor.unit
+			config["state_class"] = "measurement"
+		}
+		if sensor.deviceClass != "" {
+			config["device_class"] = sensor.deviceClass
+		}
+		if sensor.icon != "" {
+			config["icon"] = sensor.icon
+		}
+		payload, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		topic := fmt.Sprintf("%s/sensor/sundial_%s/%s/config", mqttDiscoveryPrefix, id, sensor.key)
+		if err := client.Publish(topic, payload, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slug turns s into something safe to use in topics and IDs, like
+// "denver_us_co".
+func slug(s string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, s), "_")
+}
+
+func init() {
+	mqttCmd.Flags().StringVar(&mqttBroker, "broker", "tcp://localhost:1883", "Broker to publish to, as tcp://host:port, or ssl://host:port for TLS.")
+	mqttCmd.Flags().StringVar(&mqttUsername, "username", "", "User name to connect to the broker with.")
+	mqttCmd.Flags().StringVar(&mqttPassword, "password", "", `Password to connect to the broker with. Needs --username. Others can see it in ps,
+so prefer --password-file or $SUNDIAL_MQTT_PASSWORD, which is read if neither flag is given.`)
+	mqttCmd.Flags().StringVar(&mqttPasswordFile, "password-file", "", "File to read the password to connect to the broker with from. Needs --username.")
+	mqttCmd.Flags().StringVar(&mqttTopicPrefix, "topic-prefix", "", "Prefix of the topics to publish. Defaults to sundial/ and the city, e.g. sundial/denver_us_co.")
+	mqttCmd.Flags().DurationVar(&mqttInterval, "interval", time.Minute, "How often to publish.")
+	mqttCmd.Flags().BoolVar(&mqttDiscovery, "discovery", true, "Publish Home Assistant MQTT discovery configs.")
+	mqttCmd.Flags().StringVar(&mqttDiscoveryPrefix, "discovery-prefix", "homeassistant", "Home Assistant's discovery topic prefix.")
+	mqttCmd.Flags().BoolVar(&mqttOnce, "once", false, "Publish once and exit, e.g. from cron.")
+	addPlaceFlags(mqttCmd)
+	rootCmd.AddCommand(mqttCmd)
+}
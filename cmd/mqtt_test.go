@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/riley-martine/sundial/internal/mqtt"
+	"github.com/riley-martine/sundial/internal/mqtt/mqtttest"
+	"github.com/spf13/pflag"
+)
+
+// receive returns the next n packets the broker gets.
+func receive(t *testing.T, b *mqtttest.Broker, n int) []mqtttest.Packet {
+	t.Helper()
+	var packets []mqtttest.Packet
+	for len(packets) < n {
+		select {
+		case p := <-b.Packets:
+			packets = append(packets, p)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d packets, want %d", len(packets), n)
+		}
+	}
+	return packets
+}
+
+func TestMQTTPublishes(t *testing.T) {
+	city, err := core.FindCity("Denver", "US", "CO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := mqtttest.NewBroker(t)
+	client, err := mqtt.Dial(b.URL, mqttOptions("denver_us_co", "sundial/denver_us_co/status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The will says the sensors are offline, retained
+	connect := receive(t, b, 1)[0]
+	_, rest := mqtttest.String(connect.Body)
+	if flags := rest[1]; flags&0x24 != 0x24 {
+		t.Errorf("CONNECT flags = %08b, want a retained will", flags)
+	}
+	clientID, rest := mqtttest.String(rest[4:])
+	willTopic, rest := mqtttest.String(rest)
+	willPayload, _ := mqtttest.String(rest)
+	if clientID != "sundial-denver_us_co" || willTopic != "sundial/denver_us_co/status" || willPayload != "offline" {
+		t.Errorf("CONNECT from %q with will %q on %q, want sundial-denver_us_co with offline on sundial/denver_us_co/status", clientID, willPayload, willTopic)
+	}
+
+	if err := publishDiscovery(client, city, "denver_us_co", "sundial/denver_us_co", "sundial/denver_us_co/status"); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range receive(t, b, len(mqttSensors)) {
+		sensor := mqttSensors[i]
+		topic, payload := p.Topic()
+		if want := "homeassistant/sensor/sundial_denver_us_co/" + sensor.key + "/config"; topic != want || !p.Retain() {
+			t.Errorf("discovery config on %q, retained %v, want retained on %q", topic, p.Retain(), want)
+		}
+		var config struct {
+			UniqueID          string `json:"unique_id"`
+			StateTopic        string `json:"state_topic"`
+			AvailabilityTopic string `json:"availability_topic"`
+		}
+		if err := json.Unmarshal(payload, &config); err != nil {
+			t.Fatalf("%s: %v", topic, err)
+		}
+		if config.UniqueID != "sundial_denver_us_co_"+sensor.key || config.StateTopic != "sundial/denver_us_co/"+sensor.key || config.AvailabilityTopic != "sundial/denver_us_co/status" {
+			t.Errorf("%s = %s", topic, payload)
+		}
+	}
+
+	if err := publishState(client, city, "sundial/denver_us_co"); err != nil {
+		t.Fatal(err)
+	}
+	state := map[string]string{}
+	for _, p := range receive(t, b, len(mqttSensors)) {
+		topic, payload := p.Topic()
+		if !p.Retain() {
+			t.Errorf("%s isn't retained", topic)
+		}
+		state[topic] = string(payload)
+	}
+	switch phase := state["sundial/denver_us_co/phase"]; phase {
+	case "day", "night", "golden hour", "blue hour":
+	default:
+		t.Errorf("phase = %q", phase)
+	}
+	for _, key := range []string{"percent", "elevation", "azimuth"} {
+		if _, err := strconv.ParseFloat(state["sundial/denver_us_co/"+key], 64); err != nil {
+			t.Errorf("%s = %q, want a number", key, state["sundial/denver_us_co/"+key])
+		}
+	}
+	if next := state["sundial/denver_us_co/next_event"]; next != "sunrise" && next != "sunset" {
+		t.Errorf("next_event = %q, want sunrise or sunset", next)
+	}
+	if _, err := time.Parse(time.RFC3339, state["sundial/denver_us_co/next_event_time"]); err != nil {
+		t.Errorf("next_event_time: %v", err)
+	}
+}
+
+func TestMQTTPasswordSources(t *testing.T) {
+	savedUsername, savedPassword, savedFile := mqttUsername, mqttPassword, mqttPasswordFile
+	defer func() { mqttUsername, mqttPassword, mqttPasswordFile = savedUsername, savedPassword, savedFile }()
+	parse := func(args ...string) error {
+		t.Helper()
+		mqttCmd.Flags().Visit(func(f *pflag.Flag) {
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		if err := mqttCmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return mqttCmd.PreRunE(mqttCmd, nil)
+	}
+	defer parse()
+
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SUNDIAL_MQTT_PASSWORD", "from-env")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--username", "u"}, "from-env"},
+		{[]string{"--username", "u", "--password", "from-flag"}, "from-flag"},
+		{[]string{"--username", "u", "--password-file", file}, "from-file"},
+	}
+	for _, tt := range tests {
+		if err := parse(tt.args...); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+		} else if mqttPassword != tt.want {
+			t.Errorf("%v: password %q, want %q", tt.args, mqttPassword, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--username", "u", "--password", "p", "--password-file", file},
+		{"--username", "u", "--password-file", file + ".missing"},
+		{"--password-file", file},
+	} {
+		if err := parse(args...); err == nil {
+			t.Errorf("%v succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var count int
+
+var nextCmd = &cobra.Command{
+	Use:   "next EXPRESSION --city CITY",
+	Short: "Print the next times a solar schedule happens.",
+	Long: `Print the next times a solar schedule happens.
+
+A schedule is an anchor, an optional offset, and optional weekdays:
+
+  sundial next 'sunset-30m' --city Denver
+  sundial next 'civil_dawn+10m mon-fri' --city Denver -n 5
+  sundial next '75%day' --city Denver
+
+Anchors are noon, N%day, N%night, any event from --method or --event, or one of:
+  astronomical_dawn, nautical_dawn, civil_dawn, morning_golden_hour_start,
+  sunrise, morning_golden_hour_end, evening_golden_hour_start, sunset,
+  evening_golden_hour_end, civil_dusk, nautical_dusk, astronomical_dusk
+
+  sundial next 'fajr-15m' --city Cairo --method egypt
+  sundial next 'photo_walk' --city Denver --event photo_walk:10:setting`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if count < 1 {
+			return fmt.Errorf("--count must be at least 1, not %d", count)
+		}
+		return nil
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		completions := []string{"noon"}
+		for _, e := range core.Events {
+			completions = append(completions, e.Name)
+		}
+		for _, name := range methods {
+			if m, err := core.FindMethod(name); err == nil {
+				for _, e := range m.Events {
+					completions = append(completions, e.Name)
+				}
+			}
+		}
+		for _, spec := range events {
+			if e, err := core.ParseEvent(spec); err == nil {
+				completions = append(completions, e.Name)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		schedule, err := core.ParseSchedule(args[0], city.AllEvents())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		t := resolveTime(city.Location())
+
+		times, err := schedule.Next(city, t, count)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		for _, t := range times {
+			fmt.Println(t.Format(time.UnixDate))
+		}
+	},
+}
+
+func init() {
+	nextCmd.Flags().IntVarP(&count, "count", "n", 1, "Number of times to print.")
+	addPlaceFlags(nextCmd)
+	addEventFlags(nextCmd)
+	addTimeFlag(nextCmd)
+	rootCmd.AddCommand(nextCmd)
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextRejectsCountBelowOne(t *testing.T) {
+	saved := count
+	defer func() { count = saved }()
+	for _, n := range []int{0, -3} {
+		count = n
+		if err := nextCmd.PreRunE(nextCmd, []string{"sunset"}); err == nil || !strings.Contains(err.Error(), "--count") {
+			t.Errorf("-n %d: %v, want an error about --count", n, err)
+		}
+	}
+	count = 1
+	if err := nextCmd.PreRunE(nextCmd, []string{"sunset"}); err != nil {
+		t.Errorf("-n 1: %v", err)
+	}
+}
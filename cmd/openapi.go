@@ -0,0 +1,173 @@
+package cmd
+
+// openAPIDocument describes the API serve serves.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "sundial",
+    "description": "How far through the day or night it is, solar event times, and the sun's position.",
+    "version": "1"
+  },
+  "paths": {
+    "/now": {
+      "get": {
+        "summary": "Percent through the day or night",
+        "parameters": [
+          {"$ref": "#/components/parameters/place"},
+          {"$ref": "#/components/parameters/lat"},
+          {"$ref": "#/components/parameters/lon"},
+          {"$ref": "#/components/parameters/tz"},
+          {"$ref": "#/components/parameters/elevation"},
+          {"name": "time", "in": "query", "description": "Time to use instead of now, in any format sundial --time accepts.", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "The day or night the time falls in.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Now"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/times": {
+      "get": {
+        "summary": "Solar event times for each day in a range",
+        "parameters": [
+          {"$ref": "#/components/parameters/place"},
+          {"$ref": "#/components/parameters/lat"},
+          {"$ref": "#/components/parameters/lon"},
+          {"$ref": "#/components/parameters/tz"},
+          {"$ref": "#/components/parameters/elevation"},
+          {"name": "from", "in": "query", "description": "First day, in any format sundial --time accepts. Defaults to today.", "schema": {"type": "string"}},
+          {"name": "to", "in": "query", "description": "Last day, at most 366 days after from. Defaults to from.", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "Event times for each day.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Times"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/search": {
+      "get": {
+        "summary": "Cities whose names start with a prefix",
+        "parameters": [
+          {"name": "q", "in": "query", "required": true, "description": "Start of the city's name.", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "Up to 50 matching cities.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Place"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/position": {
+      "get": {
+        "summary": "Where the sun is in the sky",
+        "parameters": [
+          {"$ref": "#/components/parameters/place"},
+          {"$ref": "#/components/parameters/lat"},
+          {"$ref": "#/components/parameters/lon"},
+          {"$ref": "#/components/parameters/tz"},
+          {"$ref": "#/components/parameters/elevation"},
+          {"name": "time", "in": "query", "description": "Time to use instead of now, in any format sundial --time accepts.", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "The sun's position.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Position"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/metrics": {
+      "get": {
+        "summary": "Prometheus gauges for each of serve's --metrics-place",
+        "description": "sundial_period_fraction, sundial_daytime, sundial_sun_elevation_degrees, sundial_sun_azimuth_degrees, sundial_day_length_seconds, and sundial_next_event_seconds, each labeled with the place, and the next event with its name.",
+        "responses": {
+          "200": {"description": "Gauges in the Prometheus text exposition format.", "content": {"text/plain; version=0.0.4": {"schema": {"type": "string"}}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "place": {"name": "place", "in": "query", "description": "City, as Name[,CountryCode[,FipsCode]]. Give either place or lat and lon.", "schema": {"type": "string"}, "example": "Denver,US,CO"},
+      "lat": {"name": "lat", "in": "query", "description": "Latitude in degrees north.", "schema": {"type": "number", "minimum": -90, "maximum": 90}},
+      "lon": {"name": "lon", "in": "query", "description": "Longitude in degrees east.", "schema": {"type": "number", "minimum": -180, "maximum": 180}},
+      "tz": {"name": "tz", "in": "query", "description": "IANA time zone for lat and lon. Defaults to the server's.", "schema": {"type": "string"}, "example": "America/Denver"},
+      "elevation": {"name": "elevation", "in": "query", "description": "Elevation for lat and lon, in meters.", "schema": {"type": "number"}}
+    },
+    "responses": {
+      "Error": {
+        "description": "The request couldn't be answered. A place matching more than one city gets a 409, with the candidates.",
+        "content": {"application/json": {"schema": {
+          "type": "object",
+          "properties": {
+            "error": {"type": "string"},
+            "candidates": {"type": "array", "items": {"$ref": "#/components/schemas/Place"}}
+          },
+          "required": ["error"]
+        }}}
+      }
+    },
+    "schemas": {
+      "Place": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "country_code": {"type": "string"},
+          "fips_code": {"type": "string"},
+          "latitude": {"type": "number"},
+          "longitude": {"type": "number"},
+          "time_zone": {"type": "string"},
+          "elevation": {"type": "number"}
+        }
+      },
+      "Event": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "example": "sunrise"},
+          "time": {"type": "string", "format": "date-time"},
+          "altitude": {"type": "number", "description": "Degrees of the sun's center above the horizon at the event."}
+        }
+      },
+      "Now": {
+        "type": "object",
+        "properties": {
+          "place": {"$ref": "#/components/schemas/Place"},
+          "at": {"type": "string", "format": "date-time"},
+          "day": {"type": "boolean"},
+          "phase": {"type": "string", "example": "morning"},
+          "symbol": {"type": "string"},
+          "percent": {"type": "number"},
+          "start": {"type": "string", "format": "date-time"},
+          "end": {"type": "string", "format": "date-time"},
+          "sunrise": {"type": "string", "format": "date-time"},
+          "sunset": {"type": "string", "format": "date-time"},
+          "next_event": {"$ref": "#/components/schemas/Event"}
+        }
+      },
+      "Times": {
+        "type": "object",
+        "properties": {
+          "place": {"$ref": "#/components/schemas/Place"},
+          "days": {"type": "array", "items": {
+            "type": "object",
+            "properties": {
+              "date": {"type": "string", "format": "date"},
+              "solar_noon": {"type": "string", "format": "date-time"},
+              "day_length_seconds": {"type": "number"},
+              "events": {"type": "array", "items": {"$ref": "#/components/schemas/Event"}}
+            }
+          }}
+        }
+      },
+      "Position": {
+        "type": "object",
+        "properties": {
+          "place": {"$ref": "#/components/schemas/Place"},
+          "at": {"type": "string", "format": "date-time"},
+          "elevation": {"type": "number", "description": "Degrees above the horizon."},
+          "azimuth": {"type": "number", "description": "Degrees clockwise from north."},
+          "hour_angle": {"type": "number", "description": "Degrees west of the meridian."},
+          "declination": {"type": "number", "description": "Degrees north of the celestial equator."}
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	overlapFrom    string
+	overlapTo      string
+	overlapMin     float64
+	overlapMax     float64
+	overlapJSONOut bool
+)
+
+var overlapCmd = &cobra.Command{
+	Use:   "overlap PLACE...",
+	Short: "Print the times when every place is in daylight.",
+	Long: `Print the times when every place is in daylight, for planning meetings
+across time zones by the sun instead of the clock.
+
+Places are given as for the world command. --from and --to are read in the
+local time zone, like --time, and the windows are printed in it too. Use --min
+and --max to only count part of the day, e.g. after the first fifth and before
+the last:
+
+  sundial overlap Denver Berlin --min 20 --max 80 --to 'next friday'`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePlace,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if overlapMin < 0 || overlapMax > 100 || overlapMin >= overlapMax {
+			return fmt.Errorf("need 0 <= --min < --max <= 100, got %g and %g", overlapMin, overlapMax)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		from := now
+		var err error
+		if overlapFrom != "" {
+			if from, err = core.ParseTime(overlapFrom, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --from: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		to := from.AddDate(0, 0, 7)
+		if overlapTo != "" {
+			if to, err = core.ParseTime(overlapTo, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --to: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		if !to.After(from) {
+			fmt.Fprintf(os.Stderr, "Error: --to (%s) must be after --from (%s)\n", to.Format(time.UnixDate), from.Format(time.UnixDate))
+			os.Exit(1)
+		}
+
+		var windows []core.Window
+		for i, arg := range args {
+			city, err := findPlace(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: ", arg)
+				exitCityError(err, "sundial overlap %s,%s,%s")
+			}
+			cityWindows := core.DaylightWindows(city, from, to, overlapMin/100, overlapMax/100)
+			if i == 0 {
+				windows = cityWindows
+			} else {
+				windows = core.IntersectWindows(windows, cityWindows)
+			}
+		}
+		// Each window is in one of the places' zones, so put them all in one
+		for i := range windows {
+			windows[i].Start = windows[i].Start.In(now.Location())
+			windows[i].End = windows[i].End.In(now.Location())
+		}
+
+		if overlapJSONOut {
+			if windows == nil {
+				windows = []core.Window{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(windows); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(windows) == 0 {
+			fmt.Fprintln(os.Stderr, "No shared daylight between", from.Format(time.UnixDate), "and", to.Format(time.UnixDate))
+			os.Exit(1)
+		}
+		for _, w := range windows {
+			fmt.Printf("%s - %s (%s)\n",
+				w.Start.Format("Mon Jan _2 15:04 MST"),
+				w.End.Format("Mon Jan _2 15:04 MST"),
+				w.Duration().Round(time.Minute))
+		}
+	},
+}
+
+func init() {
+	overlapCmd.Flags().StringVar(&overlapFrom, "from", "", "Start of the range to search, in any format --time accepts, in the local time zone. Defaults to now.")
+	overlapCmd.Flags().StringVar(&overlapTo, "to", "", "End of the range to search, in any format --time accepts, in the local time zone. Defaults to a week from --from.")
+	overlapCmd.Flags().Float64Var(&overlapMin, "min", 0, "Percent through the day each place must be past.")
+	overlapCmd.Flags().Float64Var(&overlapMax, "max", 100, "Percent through the day each place must not be past.")
+	overlapCmd.Flags().BoolVar(&overlapJSONOut, "json", false, "Print the windows as JSON.")
+	addCalculationFlags(overlapCmd)
+	rootCmd.AddCommand(overlapCmd)
+}
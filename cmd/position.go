@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var positionCmd = &cobra.Command{
+	Use:   "position --city CITY",
+	Short: "Print where the sun is in the sky.",
+	Long: `Print where the sun is in the sky: its elevation above the horizon,
+azimuth clockwise from north, hour angle, and declination, in degrees.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+		printResult(city.SolarPosition(t))
+	},
+}
+
+func init() {
+	positionCmd.Flags().StringVar(&format, "format", "", "Go template to print the position with, e.g. '{{printf \"%.1f\" .Elevation}}'.\nFields: .Elevation .Azimuth .HourAngle .Declination")
+	addPlaceFlags(positionCmd)
+	addTimeFlag(positionCmd)
+	rootCmd.AddCommand(positionCmd)
+}
@@ -3,7 +3,10 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/riley-martine/sundial/internal/core"
@@ -18,6 +21,21 @@ var (
 	countryCode string
 	fipsCode    string
 	givenTime   string
+	showMoon    bool
+	cycle       string
+	hours       string
+	format      string
+	elevation   float64
+	noDip       bool
+	refraction  string
+	pressure    float64
+	temperature float64
+	precision   string
+	methods     []string
+	events      []string
+	profileFile string
+	window      string
+	effective   bool
 )
 
 var rootCmd = &cobra.Command{
@@ -42,54 +60,254 @@ https://github.com/riley-martine/sundial`,
 
 		return completions, cobra.ShellCompDirectiveNoFileComp
 	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if hours != "" && hours != "seasonal" && hours != "koku" {
+			return fmt.Errorf("--hours must be seasonal or koku, not '%s'", hours)
+		}
+		if cycle != "day" && cycle != "year" && cycle != "season" && cycle != "month" {
+			return fmt.Errorf("--cycle must be one of day, year, season, or month, not '%s'", cycle)
+		}
+		if showMoon && cmd.Flags().Changed("cycle") {
+			return fmt.Errorf("--moon is the same as --cycle month; give one or the other")
+		}
+		if hours != "" && (showMoon || cycle != "day") {
+			return fmt.Errorf("--hours divides the day or night, so only works with --cycle day")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		city, err := core.FindCity(cityName, countryCode, fipsCode)
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+
+		switch {
+		case showMoon || cycle == "month":
+			printResult(core.GetMoon(city, t))
+			return
+		case cycle == "year":
+			printResult(core.GetYear(city, t))
+			return
+		case cycle == "season":
+			printResult(core.GetSeason(city, t))
+			return
+		}
+
+		period, err := core.GetPeriod(city, t)
 		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		switch hours {
+		case "seasonal":
+			printResult(core.SeasonalHours{Period: period})
+		case "koku":
+			printResult(core.KokuHours{Period: period})
+		default:
+			printResult(period)
+		}
+	},
+}
+
+// printResult prints result with the --format template, or as a string if
+// there isn't one.
+func printResult(result fmt.Stringer) {
+	fmt.Println(formatResult(result))
+}
+
+// formatResult is printResult without the printing.
+func formatResult(result fmt.Stringer) string {
+	if format == "" {
+		return result.String()
+	}
+
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --format: %s\n", err)
+		os.Exit(1)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	return b.String()
+}
+
+// resolveCity finds the city given by the place flags. If there isn't exactly
+// one match, it explains how to narrow the search down and exits.
+func resolveCity(cmd *cobra.Command) *core.CityInfo {
+	slog.Debug("finding city", "name", cityName, "country_code", countryCode, "fips_code", fipsCode)
+	city, err := core.FindCity(cityName, countryCode, fipsCode)
+	if err != nil {
+		exitCityError(err, "sundial --city %s --country %s --fipscode %s")
+	}
+	// Sea level is an elevation too, so check the flag was given, not its value
+	if cmd.Flags().Changed("elevation") {
+		slog.Debug("overriding the city's elevation", "dataset", city.Elevation, "elevation", elevation)
+		city.Elevation = elevation
+	}
+	for _, apply := range []func(*core.CityInfo) error{applyCalculationFlags, applyEventFlags, applyObstructionFlags} {
+		if err := apply(city); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-			var narrowingError *core.NarrowingError
-			if errors.As(err, &narrowingError) {
-				tbl := table.New("Name", "Country Code", "FIPS Code")
-				tbl.WithWriter(os.Stderr)
-				for _, city := range narrowingError.Cities {
-					tbl.AddRow(city.Name, city.CountryCode, city.FipsCode)
-				}
-				tbl.Print()
-				fmt.Fprintln(os.Stderr, "You may need to be more specific about which city you're in. Try specifying a country code and a fips code.")
-				fmt.Fprintf(os.Stderr,
-					"    e.g. sundial --city %s --country %s --fipscode %s\n",
-					narrowingError.Cities[0].Name,
-					narrowingError.Cities[0].CountryCode,
-					narrowingError.Cities[0].FipsCode)
-			}
 			os.Exit(1)
 		}
+	}
+	slog.Debug("found city", "city", city)
+	return city
+}
 
-		// This probably doesn't need ParseInLocation
-		// Adding that data back to the cities CSV balloons the size
-		t := time.Now()
-		if givenTime != "" {
-			t, err = time.Parse(time.UnixDate, givenTime)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
+// applyCalculationFlags sets the corrections and precision from
+// addCalculationFlags' flags on city.
+func applyCalculationFlags(city *core.CityInfo) error {
+	city.Horizon.Dip = !noDip
+
+	mode, err := core.ParseRefraction(refraction)
+	if err != nil {
+		return err
+	}
+	city.Horizon.Refraction = mode
+	city.Horizon.Pressure = pressure
+	city.Horizon.Temperature = temperature
+
+	city.Precision, err = core.ParsePrecision(precision)
+	return err
+}
+
+// checkCalculationFlags rejects addCalculationFlags' flags that
+// applyCalculationFlags would ignore, if cmd has them.
+func checkCalculationFlags(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	if flags.Lookup("refraction") == nil {
+		return nil
+	}
+	mode, err := core.ParseRefraction(refraction)
+	if err != nil {
+		return err
+	}
+	// The weather is only used to compute refraction, so don't ignore it
+	if mode != core.ComputedRefraction && (flags.Changed("pressure") || flags.Changed("temperature")) {
+		return errors.New("--pressure and --temperature need --refraction computed")
+	}
+	return nil
+}
+
+// applyEventFlags adds the events from addEventFlags' flags to city.
+func applyEventFlags(city *core.CityInfo) error {
+	for _, name := range methods {
+		m, err := core.FindMethod(name)
+		if err != nil {
+			return err
+		}
+		city.Events = append(city.Events, m.Events...)
+	}
+	for _, spec := range events {
+		e, err := core.ParseEvent(spec)
+		if err != nil {
+			return err
 		}
+		city.Events = append(city.Events, e)
+	}
+	return nil
+}
 
-		out, err := core.GetPeriodPercent(city, t, debug)
+// applyObstructionFlags sets the horizon profile and window from
+// addObstructionFlags' flags on city.
+func applyObstructionFlags(city *core.CityInfo) error {
+	if profileFile != "" {
+		f, err := os.Open(profileFile)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
 		}
-		fmt.Println(out)
-	},
+		defer f.Close()
+		city.Horizon.Profile, err = core.ReadProfile(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", profileFile, err)
+		}
+	}
+	if window != "" {
+		r, err := core.ParseAzimuthRange(window)
+		if err != nil {
+			return fmt.Errorf("--window: %w", err)
+		}
+		city.Horizon.Window = &r
+	}
+	city.Horizon.Effective = effective
+	return nil
+}
+
+// exitCityError prints an error from core.FindCity, with suggestions for
+// narrowing the search down if there was more than one match, and exits.
+// example formats a command line selecting a city from its name, country
+// code, and FIPS code.
+func exitCityError(err error, example string) {
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	var narrowingError *core.NarrowingError
+	if errors.As(err, &narrowingError) {
+		tbl := table.New("Name", "Country Code", "FIPS Code")
+		tbl.WithWriter(os.Stderr)
+		for _, city := range narrowingError.Cities {
+			tbl.AddRow(city.Name, city.CountryCode, city.FipsCode)
+		}
+		tbl.Print()
+		fmt.Fprintln(os.Stderr, "You may need to be more specific about which city you're in. Try specifying a country code and a fips code.")
+		fmt.Fprintf(os.Stderr,
+			"    e.g. "+example+"\n",
+			narrowingError.Cities[0].Name,
+			narrowingError.Cities[0].CountryCode,
+			narrowingError.Cities[0].FipsCode)
+	}
+	os.Exit(1)
+}
+
+// resolveTime returns the time given by --time, or now, in loc.
+func resolveTime(loc *time.Location) time.Time {
+	now := time.Now().In(loc)
+	if givenTime == "" {
+		return now
+	}
+
+	t, err := core.ParseTime(givenTime, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	slog.Debug("parsed --time", "time", givenTime, "at", t, "zone", t.Location().String())
+	if t.Before(core.GregorianStart) {
+		slog.Warn("dates before 1582-10-15 are read in the proleptic Gregorian calendar, not the Julian calendar in use then", "time", givenTime)
+	}
+	return t
 }
 
 func init() {
-	rootCmd.Flags().BoolVar(&debug, "debug", false, "Print debug logging. Default: false")
+	rootCmd.Flags().BoolVar(&showMoon, "moon", false, "Print the percent through the lunar month instead, with its phase. Same as --cycle month.")
+	rootCmd.Flags().StringVar(&cycle, "cycle", "day", `Cycle to print the percent through: day (or night), year (from winter solstice to winter solstice),
+season (from equinox to solstice or solstice to equinox), or month (from new moon to new moon).`)
+	rootCmd.RegisterFlagCompletionFunc("cycle", cobra.FixedCompletions([]string{"day", "year", "season", "month"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().StringVar(&hours, "hours", "", `Print the seasonal hour instead: seasonal for one of twelve equal hours of the day or night,
+or koku for one of the six koku of the Japanese wadokei.`)
+	rootCmd.RegisterFlagCompletionFunc("hours", cobra.FixedCompletions([]string{"seasonal", "koku"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().StringVar(&format, "format", "", `Go template to print the result with, e.g. '{{printf "%.0f" .Percent}}% {{.Phase}}'.
+Fields for both the sun and the moon: .Percent .Fraction .Phase .Symbol .At .City
+Sun only: .Sunrise .Sunset .Start .Duration .Elapsed .Day
+  .Position.Elevation .Position.Azimuth .Position.HourAngle .Position.Declination
+  .Hour .HourFraction .Koku .KokuFraction .KokuName .KokuBells
+  .SolarTime.Mean .SolarTime.Apparent .SolarTime.EquationOfTime .SolarTime.ClockOffset
+  .DayLength.Length .DayLength.ChangeSinceYesterday .DayLength.ChangeSinceSolstice
+  .DayLength.LastSolstice .DayLength.NextSolsticeTime .DayLength.NextEquinoxTime
+  (.Event "NAME") for any event, including those from --method and --event
+Moon only: .Age .Illumination .NewMoon .NextNewMoon .Rise .Set
+Year and season only: .Start .End .StartEvent .EndEvent`)
+	addPlaceFlags(rootCmd)
+	addEventFlags(rootCmd)
+	addObstructionFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&effective, "effective", false, "Count the day from when direct sun first reaches you to when it last leaves,\nover --profile and through --window.")
+	addTimeFlag(rootCmd)
+}
 
-	rootCmd.Flags().StringVar(&cityName, "city", "", "Name of city you're in. Required.")
-	rootCmd.MarkFlagRequired("city")
-	rootCmd.RegisterFlagCompletionFunc("city", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+// addPlaceFlags adds the flags resolveCity reads, with completions, to cmd.
+func addPlaceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cityName, "city", "", "Name of city you're in. Required.")
+	cmd.MarkFlagRequired("city")
+	cmd.RegisterFlagCompletionFunc("city", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		cities, err := core.FindCities(toComplete, "", "", true)
 		if err != nil {
 			cobra.CompError(err.Error())
@@ -106,8 +324,8 @@ func init() {
 		return ret, cobra.ShellCompDirectiveDefault
 	})
 
-	rootCmd.Flags().StringVar(&countryCode, "country", "", "Two-letter country code, e.g. 'US'. Not required if only one city with name.")
-	rootCmd.RegisterFlagCompletionFunc("country", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cmd.Flags().StringVar(&countryCode, "country", "", "Two-letter country code, e.g. 'US'. Not required if only one city with name.")
+	cmd.RegisterFlagCompletionFunc("country", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		cities, err := core.FindCities(cmd.Flag("city").Value.String(), toComplete, "", true)
 		if err != nil {
 			cobra.CompError(err.Error())
@@ -124,11 +342,11 @@ func init() {
 		return ret, cobra.ShellCompDirectiveDefault
 	})
 
-	rootCmd.Flags().StringVar(&fipsCode, "fipscode", "", `FIPS code of region you're in. In the US, this is the two-letter state abbreviation.
+	cmd.Flags().StringVar(&fipsCode, "fipscode", "", `FIPS code of region you're in. In the US, this is the two-letter state abbreviation.
 Otherwise, search http://download.geonames.org/export/dump/admin1CodesASCII.txt
 for '$countryCode.' and select the value after the period for the region you're in.
 Not required if only one city in country with name.`)
-	rootCmd.RegisterFlagCompletionFunc("fipscode", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cmd.RegisterFlagCompletionFunc("fipscode", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		cities, err := core.FindCities(cmd.Flag("city").Value.String(), cmd.Flag("country").Value.String(), toComplete, true)
 		if err != nil {
 			cobra.CompError(err.Error())
@@ -145,7 +363,48 @@ Not required if only one city in country with name.`)
 		return ret, cobra.ShellCompDirectiveDefault
 	})
 
-	rootCmd.Flags().StringVar(&givenTime, "time", "", "Time to convert, in time.UnixDate format. Defaults to now.")
+	cmd.Flags().Float64Var(&elevation, "elevation", 0, "Your elevation in meters, for correcting sunrise and sunset. Defaults to the city's.")
+	addCalculationFlags(cmd)
+}
+
+// addCalculationFlags adds the flags applyCalculationFlags reads to cmd.
+func addCalculationFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noDip, "no-dip", false, "Don't correct sunrise and sunset for the city's elevation.")
+	cmd.Flags().StringVar(&refraction, "refraction", "standard", `How to correct for the atmosphere bending sunlight:
+standard (34' at the horizon), computed (from --pressure and --temperature), or none (geometric).`)
+	cmd.RegisterFlagCompletionFunc("refraction", cobra.FixedCompletions([]string{"standard", "computed", "none"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.Flags().Float64Var(&pressure, "pressure", core.StandardPressure, "Air pressure in millibars, for --refraction computed.")
+	cmd.Flags().Float64Var(&temperature, "temperature", core.StandardTemperature, "Air temperature in degrees Celsius, for --refraction computed.")
+	cmd.Flags().StringVar(&precision, "precision", "standard", `How to find the sun's position: standard (NOAA, fast, about 0.01°)
+or high (NREL SPA, about 0.0003°, for solar energy work).`)
+	cmd.RegisterFlagCompletionFunc("precision", cobra.FixedCompletions([]string{"standard", "high"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// addEventFlags adds the flags applyEventFlags reads to cmd.
+func addEventFlags(cmd *cobra.Command) {
+	var descriptions []string
+	for _, m := range core.Methods {
+		descriptions = append(descriptions, fmt.Sprintf("  %-8s %s", m.Name, m.Description))
+	}
+	cmd.Flags().StringSliceVar(&methods, "method", nil, "Calculation methods to add events from, e.g. prayer times:\n"+strings.Join(descriptions, "\n"))
+	cmd.RegisterFlagCompletionFunc("method", cobra.FixedCompletions(core.MethodNames(), cobra.ShellCompDirectiveNoFileComp))
+	cmd.Flags().StringArrayVar(&events, "event", nil, `Custom event to add, as NAME:ALTITUDE:rising, NAME:ALTITUDE:setting,
+or NAME:shadow:FACTOR for when shadows have grown by FACTOR times their length since noon.`)
+}
+
+// addObstructionFlags adds the flags applyObstructionFlags reads to cmd,
+// except --effective.
+func addObstructionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profileFile, "profile", "", `File with the skyline around you, for buildings or mountains in the way.
+One "AZIMUTH ALTITUDE" point per line, in degrees; straight lines in between.`)
+	cmd.Flags().StringVar(&window, "window", "", "Directions the sun can reach you from, as FROM-TO degrees clockwise from north, e.g. 120-200.")
+}
+
+// addTimeFlag adds the flag resolveTime reads to cmd.
+func addTimeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&givenTime, "time", "", `Time to convert, in the city's time zone unless one is given. Defaults to now.
+Accepts `+core.TimeFormats+`.
+Dates before 1582-10-15 are in the proleptic Gregorian calendar, not the Julian calendar.`)
 }
 
 func Execute(version string) {
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// parseRootFlags resets the root command's flags, then parses args.
+func parseRootFlags(t *testing.T, args ...string) {
+	t.Helper()
+	rootCmd.Flags().Visit(func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	if err := rootCmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRootRejectsConflictingFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--hours", "koku", "--cycle", "year"}, "--hours"},
+		{[]string{"--hours", "seasonal", "--cycle", "season"}, "--hours"},
+		{[]string{"--hours", "seasonal", "--cycle", "month"}, "--hours"},
+		{[]string{"--hours", "seasonal", "--moon"}, "--hours"},
+		{[]string{"--moon", "--cycle", "month"}, "--moon"},
+		{[]string{"--moon", "--cycle", "year"}, "--moon"},
+		{[]string{"--moon", "--cycle", "day"}, "--moon"},
+	}
+	for _, tt := range tests {
+		parseRootFlags(t, tt.args...)
+		err := rootCmd.PreRunE(rootCmd, nil)
+		if err == nil {
+			t.Errorf("%v succeeded, want an error about %s", tt.args, tt.want)
+		} else if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%v: %q, want an error about %s", tt.args, err, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--hours", "koku"},
+		{"--hours", "seasonal", "--cycle", "day"},
+		{"--moon"},
+		{"--cycle", "month"},
+	} {
+		parseRootFlags(t, args...)
+		if err := rootCmd.PreRunE(rootCmd, nil); err != nil {
+			t.Errorf("%v: %v", args, err)
+		}
+	}
+	parseRootFlags(t)
+}
+
+func TestWeatherNeedsComputedRefraction(t *testing.T) {
+	// Giving the default values explicitly still counts
+	for _, args := range [][]string{
+		{"--pressure", "1010", "--temperature", "10"},
+		{"--pressure", "900"},
+		{"--temperature", "10", "--refraction", "none"},
+	} {
+		parseRootFlags(t, args...)
+		if err := checkCalculationFlags(rootCmd); err == nil || !strings.Contains(err.Error(), "--refraction computed") {
+			t.Errorf("%v: %v, want an error about --refraction computed", args, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"--refraction", "none"},
+		{"--pressure", "1010", "--temperature", "10", "--refraction", "computed"},
+	} {
+		parseRootFlags(t, args...)
+		if err := checkCalculationFlags(rootCmd); err != nil {
+			t.Errorf("%v: %v", args, err)
+		}
+	}
+	parseRootFlags(t)
+}
@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr   string
+	corsOrigins []string
+	maxAge      time.Duration
+)
+
+// Longest span /times will list, in days.
+const maxTimesDays = 366
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a JSON API for dashboards and wall displays.",
+	Long: `Serve a JSON API over HTTP. Places are given as place=Name[,CountryCode[,FipsCode]],
+or as lat= and lon=, with an optional IANA tz= and elevation= in meters.
+Times are given as time=, from=, and to=, in any format --time accepts.
+
+  GET /now?place=Denver              Percent through the day or night
+  GET /times?lat=39.74&lon=-104.98   Solar events for each day from from= to to=
+  GET /search?q=Den                  Cities whose names start with q
+  GET /position?place=Denver         Where the sun is in the sky
+  GET /openapi.json                  OpenAPI description of all of the above
+  GET /metrics                       Prometheus gauges for each --metrics-place
+
+Responses may be cached until the next solar event, or for --max-age if that's
+sooner.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Apply the flags once up front, so bad values fail now instead of
+		// on the first request.
+		for _, apply := range []func(*core.CityInfo) error{applyCalculationFlags, applyEventFlags} {
+			if err := apply(&core.CityInfo{}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		cities := make([]*core.CityInfo, len(metricsPlaces))
+		for i, placeSpec := range metricsPlaces {
+			var err error
+			if cities[i], err = findPlace(placeSpec); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: ", placeSpec)
+				exitCityError(err, "sundial serve --metrics-place %s,%s,%s")
+			}
+		}
+
+		server := &http.Server{
+			Addr:    serveAddr,
+			Handler: withCORS(newAPIMux(cities)),
+			// Don't let slow or stalled clients hold connections open
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		}
+		slog.Info("listening", "addr", serveAddr)
+		if err := server.ListenAndServe(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// newAPIMux returns a mux with every API endpoint, with metrics for
+// metricsCities.
+func newAPIMux(metricsCities []*core.CityInfo) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/now", handleNow)
+	mux.HandleFunc("/times", handleTimes)
+	mux.HandleFunc("/search", handleSearch)
+	mux.HandleFunc("/position", handlePosition)
+	mux.HandleFunc("/metrics", handleMetrics(metricsCities))
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		setCacheFor(w, 24*time.Hour)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(openAPIDocument))
+	})
+	return mux
+}
+
+// withCORS lets browsers on --cors-origin call next, and answers their
+// preflight requests.
+func withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		for _, allowed := range corsOrigins {
+			if allowed == "*" || allowed == origin {
+				w.Header().Set("Access-Control-Allow-Origin", allowed)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				if allowed != "*" {
+					w.Header().Add("Vary", "Origin")
+				}
+				break
+			}
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// An apiError is the body of every error response.
+type apiError struct {
+	status     int
+	Message    string           `json:"error"`
+	Candidates []*core.CityInfo `json:"candidates,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func badRequest(format string, args ...interface{}) *apiError {
+	return &apiError{status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *apiError
+	if !errors.As(err, &e) {
+		e = &apiError{status: http.StatusUnprocessableEntity, Message: err.Error()}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, e.status, e)
+}
+
+// setCacheFor lets clients cache the response for d.
+func setCacheFor(w http.ResponseWriter, d time.Duration) {
+	seconds := int(d.Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
+	w.Header().Set("Expires", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+}
+
+// setCacheUntilNextEvent lets clients cache a response about the sun at at
+// until the next solar event, when it changes phase, but for no longer than
+// --max-age. Responses about a given time never change, so keep for a day.
+func setCacheUntilNextEvent(w http.ResponseWriter, r *http.Request, city *core.CityInfo, at time.Time) {
+	if r.URL.Query().Get("time") != "" {
+		setCacheFor(w, 24*time.Hour)
+		return
+	}
+	d := maxAge
+	if _, next, ok := city.NextEvent(at, city.AllEvents()...); ok && next.Sub(at) < d {
+		d = next.Sub(at)
+	}
+	setCacheFor(w, d)
+}
+
+// apiPlace returns the place a request is about.
+func apiPlace(r *http.Request) (*core.CityInfo, error) {
+	q := r.URL.Query()
+	var city *core.CityInfo
+	if place := q.Get("place"); place != "" {
+		var err error
+		city, err = lookUpPlace(place)
+		var narrowingError *core.NarrowingError
+		if errors.As(err, &narrowingError) {
+			return nil, &apiError{
+				status:     http.StatusConflict,
+				Message:    fmt.Sprintf("'%s' matches more than one city; add a country code and FIPS code", place),
+				Candidates: narrowingError.Cities,
+			}
+		} else if err != nil {
+			return nil, &apiError{status: http.StatusNotFound, Message: err.Error()}
+		}
+	} else if q.Get("lat") != "" || q.Get("lon") != "" {
+		lat, err := strconv.ParseFloat(q.Get("lat"), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, badRequest("lat must be a number from -90 to 90")
+		}
+		lon, err := strconv.ParseFloat(q.Get("lon"), 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return nil, badRequest("lon must be a number from -180 to 180")
+		}
+		city = &core.CityInfo{Name: fmt.Sprintf("%g,%g", lat, lon), Latitude: lat, Longitude: lon}
+		// Coordinates have no zone of their own, so default to the server's
+		city.TimeZone = "Local"
+		if tz := q.Get("tz"); tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				return nil, badRequest("unknown time zone '%s'", tz)
+			}
+			city.TimeZone = tz
+		}
+		if elevation := q.Get("elevation"); elevation != "" {
+			if city.Elevation, err = strconv.ParseFloat(elevation, 64); err != nil {
+				return nil, badRequest("elevation must be a number of meters")
+			}
+		}
+	} else {
+		return nil, badRequest("give a place= or lat= and lon=")
+	}
+	for _, apply := range []func(*core.CityInfo) error{applyCalculationFlags, applyEventFlags} {
+		if err := apply(city); err != nil {
+			return nil, badRequest("%s", err)
+		}
+	}
+	slog.Debug("found place", "city", city)
+	return city, nil
+}
+
+// apiTime returns the time in the request's param, or fallback if it
+// doesn't have one.
+func apiTime(r *http.Request, param string, loc *time.Location, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := core.ParseTime(value, time.Now().In(loc))
+	if err != nil {
+		return time.Time{}, badRequest("%s: %s", param, err)
+	}
+	return t, nil
+}
+
+// An apiEvent is when a solar event happens.
+type apiEvent struct {
+	Name     string    `json:"name"`
+	Time     time.Time `json:"time"`
+	Altitude float64   `json:"altitude"`
+}
+
+type nowResponse struct {
+	Place     *core.CityInfo `json:"place"`
+	At        time.Time      `json:"at"`
+	Day       bool           `json:"day"`
+	Phase     string         `json:"phase"`
+	Symbol    string         `json:"symbol"`
+	Percent   float64        `json:"percent"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Sunrise   time.Time      `json:"sunrise"`
+	Sunset    time.Time      `json:"sunset"`
+	NextEvent *apiEvent      `json:"next_event,omitempty"`
+}
+
+func handleNow(w http.ResponseWriter, r *http.Request) {
+	city, err := apiPlace(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	loc := city.Location()
+	at, err := apiTime(r, "time", loc, time.Now().In(loc))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	at = at.In(loc)
+	period, err := core.GetPeriod(city, at)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := nowResponse{
+		Place:   city,
+		At:      at,
+		Day:     period.Day,
+		Phase:   period.Phase(),
+		Symbol:  period.Symbol(),
+		Percent: period.Percent(),
+		Start:   period.Start,
+		End:     period.Start.Add(period.Duration),
+		Sunrise: period.Sunrise,
+		Sunset:  period.Sunset,
+	}
+	if e, t, ok := city.NextEvent(at, city.AllEvents()...); ok {
+		resp.NextEvent = &apiEvent{Name: e.Name, Time: t, Altitude: city.EventAltitude(e, t)}
+	}
+	setCacheUntilNextEvent(w, r, city, at)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type dayTimes struct {
+	Date             string     `json:"date"`
+	SolarNoon        time.Time  `json:"solar_noon"`
+	DayLengthSeconds float64    `json:"day_length_seconds"`
+	Events           []apiEvent `json:"events"`
+}
+
+type timesResponse struct {
+	Place *core.CityInfo `json:"place"`
+	Days  []dayTimes     `json:"days"`
+}
+
+func handleTimes(w http.ResponseWriter, r *http.Request) {
+	city, err := apiPlace(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	loc := city.Location()
+	now := time.Now().In(loc)
+	from, err := apiTime(r, "from", loc, now)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	to, err := apiTime(r, "to", loc, from)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	from, to = from.In(loc), to.In(loc)
+	if to.Sub(from) > maxTimesDays*24*time.Hour {
+		writeError(w, badRequest("from= and to= can be at most %d days apart", maxTimesDays))
+		return
+	}
+
+	resp := timesResponse{Place: city, Days: []dayTimes{}}
+	y, m, d := from.Date()
+	for day := time.Date(y, m, d, 12, 0, 0, 0, loc); !day.After(to) || sameDay(day, to); day = day.AddDate(0, 0, 1) {
+		dt := dayTimes{
+			Date:             day.Format("2006-01-02"),
+			SolarNoon:        city.SolarNoon(day),
+			DayLengthSeconds: city.DayLength(day).Length.Seconds(),
+			Events:           []apiEvent{},
+		}
+		for _, e := range city.AllEvents() {
+			if t, ok := city.EventTime(e, day); ok {
+				dt.Events = append(dt.Events, apiEvent{Name: e.Name, Time: t, Altitude: city.EventAltitude(e, day)})
+			}
+		}
+		resp.Days = append(resp.Days, dt)
+	}
+
+	if r.URL.Query().Get("from") == "" {
+		// Today's times are good until tomorrow
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+		setCacheFor(w, tomorrow.Sub(now))
+	} else {
+		setCacheFor(w, 24*time.Hour)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// Most cities /search returns.
+const maxSearchResults = 50
+
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, badRequest("give a city name to search for as q="))
+		return
+	}
+	cities, err := core.FindCities(q, "", "", true)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(cities) > maxSearchResults {
+		cities = cities[:maxSearchResults]
+	}
+	setCacheFor(w, 24*time.Hour)
+	writeJSON(w, http.StatusOK, cities)
+}
+
+type positionResponse struct {
+	Place *core.CityInfo `json:"place"`
+	At    time.Time      `json:"at"`
+	core.Position
+}
+
+func handlePosition(w http.ResponseWriter, r *http.Request) {
+	city, err := apiPlace(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	loc := city.Location()
+	at, err := apiTime(r, "time", loc, time.Now().In(loc))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	at = at.In(loc)
+	setCacheUntilNextEvent(w, r, city, at)
+	writeJSON(w, http.StatusOK, positionResponse{Place: city, At: at, Position: city.SolarPosition(at)})
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on.")
+	serveCmd.Flags().StringSliceVar(&corsOrigins, "cors-origin", nil, "Origins browsers may call the API from, e.g. http://wall.local:3000, or * for any.")
+	serveCmd.Flags().StringArrayVar(&metricsPlaces, "metrics-place", nil, "Place to export metrics for at /metrics, as Name[,CountryCode[,FipsCode]]. Repeat for more places.")
+	serveCmd.RegisterFlagCompletionFunc("metrics-place", completePlace)
+	serveCmd.Flags().DurationVar(&maxAge, "max-age", time.Minute, "Longest to let clients cache responses about the current time.")
+	addCalculationFlags(serveCmd)
+	addEventFlags(serveCmd)
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPIDocumentsEveryPath(t *testing.T) {
+	var doc struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(openAPIDocument), &doc); err != nil {
+		t.Fatalf("openAPIDocument isn't JSON: %v", err)
+	}
+	for _, path := range []string{"/now", "/times", "/search", "/position", "/metrics"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("openAPIDocument doesn't document %s", path)
+		}
+	}
+}
+
+func TestAPIPlaceErrors(t *testing.T) {
+	mux := newAPIMux(nil)
+	get := func(url string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w.Code
+	}
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"/now?place=Denver,US,CO", http.StatusOK},
+		{"/now?place=Nowhereville", http.StatusNotFound},
+		{"/now?place=Springfield", http.StatusConflict},
+		{"/now?lat=100&lon=0", http.StatusBadRequest},
+		{"/now", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := get(tt.url); got != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.url, got, tt.want)
+		}
+	}
+
+	// Bad flags are caught when serve starts, but shouldn't kill the server
+	// if they get this far
+	saved := refraction
+	refraction = "bogus"
+	defer func() { refraction = saved }()
+	for _, url := range []string{"/now?place=Denver,US,CO", "/now?lat=39.74&lon=-104.98"} {
+		if got := get(url); got != http.StatusBadRequest {
+			t.Errorf("GET %s with a bad --refraction = %d, want %d", url, got, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shadowHeight string
+	shadowTable  bool
+	shadowPlot   bool
+)
+
+// Radius of the polar plot in rows. Columns are twice as dense, since
+// characters are about twice as tall as they are wide.
+const plotRadius = 10
+
+var shadowCmd = &cobra.Command{
+	Use:   "shadow --city CITY --height HEIGHT",
+	Short: "Print how long a shadow is and which way it points.",
+	Long: `Print how long the shadow of a vertical object is on level ground, and the
+bearing it points toward, clockwise from north.
+
+The shadow is given in the same unit as the height, e.g. 2m, 150cm, or 6ft.
+Use --table for the shadow at each hour of the day, and --plot to draw where
+the tip of the shadow is each hour, seen from above with north up:
+
+  sundial shadow --city Denver --height 2m --table --plot`,
+	Run: func(cmd *cobra.Command, args []string) {
+		height, unit, err := parseHeight(shadowHeight)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+
+		shadow, ok := city.Shadow(t, height)
+		shadow.Unit = unit
+		if !ok && format == "" {
+			fmt.Println("No shadow: the sun is down.")
+		} else {
+			printResult(shadow)
+		}
+
+		var hourly []core.Shadow
+		y, m, d := t.Date()
+		for hour := 0; hour < 24; hour++ {
+			if s, ok := city.Shadow(time.Date(y, m, d, hour, 0, 0, 0, t.Location()), height); ok {
+				s.Unit = unit
+				hourly = append(hourly, s)
+			}
+		}
+
+		if shadowTable {
+			fmt.Println()
+			tbl := table.New("Time", "Sun Elevation", "Length", "Bearing")
+			for _, s := range hourly {
+				tbl.AddRow(
+					s.At.Format("15:04"),
+					fmt.Sprintf("%.1f°", s.Elevation),
+					fmt.Sprintf("%.2f%s", s.Length, s.Unit),
+					fmt.Sprintf("%.0f° %s", s.Bearing, s.Direction()),
+				)
+			}
+			tbl.Print()
+		}
+		if shadowPlot {
+			fmt.Println()
+			var current *core.Shadow
+			if ok {
+				current = &shadow
+			}
+			plotShadows(hourly, current, height, unit)
+		}
+	},
+}
+
+// parseHeight parses a length like "2m" or "6ft" into its number and unit. A
+// bare number is in meters.
+func parseHeight(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := s, "m"
+	if end >= 0 {
+		number, unit = s[:end], strings.TrimSpace(s[end:])
+	}
+	switch unit {
+	case "m", "cm", "mm", "ft", "in":
+	default:
+		return 0, "", fmt.Errorf("unknown unit '%s' in --height: expected m, cm, mm, ft, or in", unit)
+	}
+	height, err := strconv.ParseFloat(number, 64)
+	if err != nil || height <= 0 {
+		return 0, "", fmt.Errorf("--height must be a positive length like 2m, not '%s'", s)
+	}
+	return height, unit, nil
+}
+
+// plotShadows draws where the tip of each shadow is, labeled with the last
+// digit of its hour, around the object at the center. current, if not nil, is
+// drawn as a *. Shadows longer than four times the height are left off.
+func plotShadows(shadows []core.Shadow, current *core.Shadow, height float64, unit string) {
+	radius := 0.0
+	for _, s := range shadows {
+		radius = math.Max(radius, math.Min(s.Length, 4*height))
+	}
+	if radius == 0 {
+		fmt.Println("No shadows to plot: the sun is down all day.")
+		return
+	}
+
+	grid := make([][]rune, 2*plotRadius+1)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(" ", 4*plotRadius+1))
+		for col := range grid[row] {
+			dx, dy := float64(col-2*plotRadius)/2, float64(row-plotRadius)
+			if math.Abs(math.Hypot(dx, dy)-plotRadius) < 0.5 {
+				grid[row][col] = '.'
+			}
+		}
+	}
+	grid[0][2*plotRadius] = 'N'
+	grid[2*plotRadius][2*plotRadius] = 'S'
+	grid[plotRadius][0] = 'W'
+	grid[plotRadius][4*plotRadius] = 'E'
+	grid[plotRadius][2*plotRadius] = 'o'
+
+	place := func(s core.Shadow, mark rune) {
+		if s.Length > radius {
+			return
+		}
+		r := s.Length / radius * plotRadius
+		bearing := s.Bearing * math.Pi / 180
+		row := plotRadius - int(math.Round(r*math.Cos(bearing)))
+		col := 2*plotRadius + int(math.Round(2*r*math.Sin(bearing)))
+		grid[row][col] = mark
+	}
+	for _, s := range shadows {
+		place(s, rune('0'+s.At.Hour()%10))
+	}
+	if current != nil {
+		place(*current, '*')
+	}
+
+	for _, row := range grid {
+		fmt.Println(strings.TrimRight(string(row), " "))
+	}
+	fmt.Printf("Object at o, shadow tips labeled by the last digit of the hour, * now. Radius %.2f%s.\n", radius, unit)
+}
+
+func init() {
+	shadowCmd.Flags().StringVar(&shadowHeight, "height", "1m", "Height of the object, e.g. 2m, 150cm, or 6ft. A bare number is in meters.")
+	shadowCmd.Flags().BoolVar(&shadowTable, "table", false, "Also print the shadow at each hour of the day.")
+	shadowCmd.Flags().BoolVar(&shadowPlot, "plot", false, "Also draw where the tip of the shadow is each hour, with north up.")
+	shadowCmd.Flags().StringVar(&format, "format", "", "Go template to print the shadow with, e.g. '{{printf \"%.1f\" .Length}}'.\nFields: .Length .Bearing .Direction .Ratio .Elevation .Height .Unit .At")
+	addPlaceFlags(shadowCmd)
+	addTimeFlag(shadowCmd)
+	rootCmd.AddCommand(shadowCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var solarTimeCmd = &cobra.Command{
+	Use:   "solartime --city CITY",
+	Short: "Print what a sundial would read.",
+	Long: `Print what a sundial would read (local apparent solar time), local mean
+solar time, the equation of time between them, and how far the sundial is
+from the clock on the wall.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+		printResult(city.SolarTime(t))
+	},
+}
+
+func init() {
+	solarTimeCmd.Flags().StringVar(&format, "format", "", "Go template to print the solar time with, e.g. '{{.Apparent.Format \"15:04\"}}'.\nFields: .Civil .Mean .Apparent .EquationOfTime .ClockOffset")
+	addPlaceFlags(solarTimeCmd)
+	addTimeFlag(solarTimeCmd)
+	rootCmd.AddCommand(solarTimeCmd)
+}
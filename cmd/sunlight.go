@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var sunlightCmd = &cobra.Command{
+	Use:   "sunlight --city CITY --profile FILE --window FROM-TO",
+	Short: "Print when direct sun reaches you past what's in the way.",
+	Long: `Print when direct sun reaches you, given the skyline around you and the
+directions a window faces, and the effective sunrise and sunset: when direct
+sun first reaches you and last leaves.
+
+For a window facing 120° to 200° with buildings up to 15° in the way:
+
+  printf '0 15\n' > skyline.txt
+  sundial sunlight --city Denver --profile skyline.txt --window 120-200
+
+Use the same flags with --effective to get the day percent by them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+
+		y, m, d := t.Date()
+		windows := city.DirectSunWindows(
+			time.Date(y, m, d, 0, 0, 0, 0, t.Location()),
+			time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()),
+		)
+		if len(windows) == 0 {
+			fmt.Fprintln(os.Stderr, "No direct sun on", t.Format("Mon Jan _2 2006"))
+			os.Exit(1)
+		}
+
+		fmt.Println("Effective sunrise:", windows[0].Start.Format("15:04:05 MST"))
+		fmt.Println("Effective sunset: ", windows[len(windows)-1].End.Format("15:04:05 MST"))
+		fmt.Println()
+		var total time.Duration
+		for _, w := range windows {
+			fmt.Printf("%s - %s (%s)\n", w.Start.Format("15:04:05"), w.End.Format("15:04:05"), w.Duration().Round(time.Minute))
+			total += w.Duration()
+		}
+		fmt.Println("Total direct sun:", total.Round(time.Minute))
+	},
+}
+
+func init() {
+	addPlaceFlags(sunlightCmd)
+	addObstructionFlags(sunlightCmd)
+	addTimeFlag(sunlightCmd)
+	rootCmd.AddCommand(sunlightCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var timesCmd = &cobra.Command{
+	Use:   "times --city CITY",
+	Short: "Print the times of the day's solar events.",
+	Long: `Print the times of the day's solar events, from astronomical dawn to
+astronomical dusk, including golden hour and blue hour.
+
+Events the sun doesn't reach that day, as happens near the poles, are left out.
+Add prayer times, zmanim, or your own events with --method and --event:
+
+  sundial times --city Cairo --method egypt,hanafi
+  sundial times --city Denver --event photo_walk:10:setting
+
+Below them is the length of the day, how it's changing, and the dates of the
+next solstice and equinox.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		city := resolveCity(cmd)
+		t := resolveTime(city.Location())
+
+		type row struct {
+			name     string
+			at       time.Time
+			altitude string
+		}
+		rows := []row{{name: "solar_noon", at: city.SolarNoon(t), altitude: "-"}}
+		for _, e := range city.AllEvents() {
+			if at, ok := city.EventTime(e, t); ok {
+				rows = append(rows, row{name: e.Name, at: at, altitude: fmt.Sprintf("%.3g°", city.EventAltitude(e, t))})
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].at.Before(rows[j].at)
+		})
+
+		tbl := table.New("Event", "Time", "Altitude")
+		for _, r := range rows {
+			tbl.AddRow(r.name, r.at.Format("Mon 15:04:05 MST"), r.altitude)
+		}
+		tbl.Print()
+
+		dl := city.DayLength(t)
+		fmt.Println()
+		tbl = table.New("Day Length", "Since Yesterday", "Since Solstice", "Next Solstice", "Next Equinox")
+		tbl.AddRow(
+			dl.Length.Round(time.Second),
+			core.FormatOffset(dl.ChangeSinceYesterday),
+			core.FormatOffset(dl.ChangeSinceSolstice),
+			dl.NextSolsticeTime.Format("Jan 2 15:04"),
+			dl.NextEquinoxTime.Format("Jan 2 15:04"),
+		)
+		tbl.Print()
+	},
+}
+
+func init() {
+	addPlaceFlags(timesCmd)
+	addEventFlags(timesCmd)
+	addTimeFlag(timesCmd)
+	rootCmd.AddCommand(timesCmd)
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var sortBy string
+
+// worldRow is everything the world view prints about one place.
+type worldRow struct {
+	placeSpec string
+	city      *core.CityInfo
+	loc       *time.Location
+	period    *core.Period
+	next      core.Event
+	nextTime  time.Time
+	hasNext   bool
+	err       error
+}
+
+var worldCmd = &cobra.Command{
+	Use:   "world PLACE...",
+	Short: "Print the day or night percent for several places at once.",
+	Long: `Print the day or night percent for several places at once.
+
+Each place is a city name, optionally followed by a country code and a FIPS
+code, separated by commas:
+
+  sundial world Denver Berlin Jakarta
+  sundial world Washington,US,DC Washington,GB --sort phase
+
+Each place's local time and next event are in its own time zone. --time is
+read in yours, since there's no one city to read it in.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePlace,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if sortBy != "longitude" && sortBy != "phase" && sortBy != "none" {
+			return fmt.Errorf("--sort must be one of longitude, phase, or none, not '%s'", sortBy)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		t := resolveTime(time.Local)
+
+		rows := make([]*worldRow, len(args))
+		var wg sync.WaitGroup
+		for i, arg := range args {
+			wg.Add(1)
+			go func(i int, arg string) {
+				defer wg.Done()
+				rows[i] = computeWorldRow(arg, t)
+			}(i, arg)
+		}
+		wg.Wait()
+
+		for _, row := range rows {
+			if row.err != nil {
+				fmt.Fprintf(os.Stderr, "%s: ", row.placeSpec)
+				exitCityError(row.err, "sundial world %s,%s,%s")
+			}
+		}
+
+		switch sortBy {
+		case "longitude":
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rows[i].city.Longitude < rows[j].city.Longitude
+			})
+		case "phase":
+			// Order by position in the whole day, starting at sunrise
+			position := func(r *worldRow) float64 {
+				if r.period.Day {
+					return r.period.Fraction()
+				}
+				return 1 + r.period.Fraction()
+			}
+			sort.SliceStable(rows, func(i, j int) bool {
+				return position(rows[i]) < position(rows[j])
+			})
+		}
+
+		printWorld(os.Stdout, rows, t)
+	},
+}
+
+// printWorld prints a table of rows at t, with each place's times in its own
+// time zone.
+func printWorld(w io.Writer, rows []*worldRow, t time.Time) {
+	tbl := table.New("Place", "Local Time", "Phase", "Percent", "Next Event")
+	tbl.WithWriter(w)
+	for _, row := range rows {
+		next := "none within a year"
+		if row.hasNext {
+			next = fmt.Sprintf("%s %s", row.next, row.nextTime.In(row.loc).Format("Mon 15:04"))
+		}
+		tbl.AddRow(
+			fmt.Sprintf("%s, %s, %s", row.city.Name, row.city.CountryCode, row.city.FipsCode),
+			t.In(row.loc).Format("Mon 15:04 MST"),
+			fmt.Sprintf("%s %s", row.period.Symbol(), row.period.Phase()),
+			fmt.Sprintf("%.0f%%", row.period.Fraction()*100),
+			next,
+		)
+	}
+	tbl.Print()
+}
+
+// findPlace finds the city given as "Name[,CountryCode[,FipsCode]]", with the
+// horizon flags applied.
+func findPlace(placeSpec string) (*core.CityInfo, error) {
+	city, err := lookUpPlace(placeSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyCalculationFlags(city); err != nil {
+		return nil, err
+	}
+	slog.Debug("found place", "place", placeSpec, "city", city)
+	return city, nil
+}
+
+// lookUpPlace is findPlace without applying any flags, so its errors are
+// only from core.FindCity.
+func lookUpPlace(placeSpec string) (*core.CityInfo, error) {
+	parts := strings.SplitN(placeSpec, ",", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return core.FindCity(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]))
+}
+
+// completePlace completes the city name of a place argument.
+func completePlace(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cities, err := core.FindCities(toComplete, "", "", true)
+	if err != nil {
+		cobra.CompError(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ret []string
+	for _, city := range cities {
+		ret = append(ret, city.Name)
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+// computeWorldRow finds the place given as "Name[,CountryCode[,FipsCode]]"
+// and computes its period at t, in the place's own time zone.
+func computeWorldRow(placeSpec string, t time.Time) *worldRow {
+	row := &worldRow{placeSpec: placeSpec}
+	row.city, row.err = findPlace(placeSpec)
+	if row.err != nil {
+		return row
+	}
+
+	// Location logs when it falls back to UTC, so only call it once
+	row.loc = row.city.Location()
+	at := t.In(row.loc)
+	row.period, row.err = core.GetPeriod(row.city, at)
+	if row.err != nil {
+		return row
+	}
+	row.next, row.nextTime, row.hasNext = row.city.NextEvent(at, core.Sunrise, core.Sunset)
+	return row
+}
+
+func init() {
+	worldCmd.Flags().StringVar(&sortBy, "sort", "longitude", "How to order places: longitude, phase, or none.")
+	worldCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{"longitude", "phase", "none"}, cobra.ShellCompDirectiveNoFileComp))
+	addCalculationFlags(worldCmd)
+	addTimeFlag(worldCmd)
+	rootCmd.AddCommand(worldCmd)
+}
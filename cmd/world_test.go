@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintWorldUsesEachPlacesZone(t *testing.T) {
+	// The machine's zone shouldn't matter
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	at := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	var rows []*worldRow
+	for _, place := range []string{"Denver,US,CO", "Berlin,DE"} {
+		row := computeWorldRow(place, at)
+		if row.err != nil {
+			t.Fatalf("%s: %v", place, row.err)
+		}
+		rows = append(rows, row)
+	}
+	var out bytes.Buffer
+	printWorld(&out, rows, at)
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("printWorld printed:\n%s", out.String())
+	}
+	tests := []struct {
+		line      string
+		localTime string
+		next      string
+	}{
+		{lines[1], "Mon 12:00 MDT", "sunset Mon 18:20"},
+		{lines[2], "Mon 20:00 CEST", "sunrise Tue 07:39"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.line, tt.localTime) {
+			t.Errorf("row %q doesn't have local time %q", tt.line, tt.localTime)
+		}
+		if !strings.Contains(tt.line, tt.next) {
+			t.Errorf("row %q doesn't have next event %q", tt.line, tt.next)
+		}
+	}
+}
@@ -1,9 +1,8 @@
 module github.com/riley-martine/sundial
 
-go 1.19
+go 1.21
 
 require (
-	github.com/kelvins/sunrisesunset v0.0.0-20210220141756-39fa1bd816d5
 	github.com/rodaine/table v1.1.0
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/pflag v1.0.5
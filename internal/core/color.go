@@ -0,0 +1,63 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ColorSettings are the screen color temperatures and brightnesses to use by
+// day and by night, and the band of solar elevations to fade between them
+// in. The defaults are those of redshift and gammastep, except for dimming a
+// little at night.
+type ColorSettings struct {
+	DayTemperature   int // Kelvin
+	NightTemperature int
+	DayBrightness    float64 // From 0 to 1
+	NightBrightness  float64
+	// Above HighElevation it's day, and below LowElevation it's night. In
+	// degrees.
+	HighElevation float64
+	LowElevation  float64
+}
+
+var DefaultColorSettings = ColorSettings{
+	DayTemperature:   6500,
+	NightTemperature: 4500,
+	DayBrightness:    1,
+	NightBrightness:  0.8,
+	HighElevation:    3,
+	LowElevation:     -6,
+}
+
+// A ScreenColor is the color temperature and brightness to set a screen to.
+type ScreenColor struct {
+	At          time.Time
+	Elevation   float64 // Of the sun, in degrees
+	Fraction    float64 // How far from night (0) to day (1) the settings are
+	Temperature int     // Kelvin
+	Brightness  float64 // From 0 to 1
+}
+
+// ScreenColor returns the screen color for the sun's elevation at the given
+// instant.
+func (c *CityInfo) ScreenColor(at time.Time, s ColorSettings) ScreenColor {
+	elevation := c.SolarPosition(at).Elevation
+	fraction := (elevation - s.LowElevation) / (s.HighElevation - s.LowElevation)
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	return ScreenColor{
+		At:          at,
+		Elevation:   elevation,
+		Fraction:    fraction,
+		Temperature: s.NightTemperature + int(math.Round(fraction*float64(s.DayTemperature-s.NightTemperature))),
+		Brightness:  s.NightBrightness + fraction*(s.DayBrightness-s.NightBrightness),
+	}
+}
+
+func (sc ScreenColor) String() string {
+	return fmt.Sprintf("%dK %.0f%%", sc.Temperature, sc.Brightness*100)
+}
@@ -1,11 +1,15 @@
 package core
 
 import (
+	"crypto/sha256"
 	"embed"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
-	"github.com/kelvins/sunrisesunset"
+	"hash"
 	"io"
+	"log/slog"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -19,95 +23,239 @@ What I want to display: percent through the apparent solar day we are
 var fs embed.FS
 
 type CityInfo struct {
-	Name        string
-	CountryCode string
-	FipsCode    string
-	Latitude    float64
-	Longitude   float64
+	Name        string  `json:"name"`
+	CountryCode string  `json:"country_code"`
+	FipsCode    string  `json:"fips_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	TimeZone    string  `json:"time_zone,omitempty"` // IANA name, e.g. "America/Denver". May be empty.
+	Elevation   float64 `json:"elevation"`           // Meters above sea level
+
+	// Corrections to apply to sunrise and sunset. Not from the dataset.
+	Horizon Horizon `json:"-"`
+	// How to find the sun's position. Not from the dataset.
+	Precision Precision `json:"-"`
+	// Events to calculate besides the built in ones, e.g. from a Method. Not
+	// from the dataset.
+	Events []Event `json:"-"`
 }
 
 func (c *CityInfo) String() string {
 	return fmt.Sprintf("{%s, %s, %s, %0.2f, %0.2f}", c.Name, c.CountryCode, c.FipsCode, c.Latitude, c.Longitude)
 }
 
+// Location returns the city's time zone. If the dataset doesn't have one, or
+// it isn't in the system's zone database, it logs a warning and returns the
+// local time zone.
+func (c *CityInfo) Location() *time.Location {
+	if c.TimeZone == "" {
+		slog.Warn("city has no time zone; using the local one", "city", c.Name, "local", time.Local.String())
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		slog.Warn("can't load the city's time zone; using the local one", "city", c.Name, "time_zone", c.TimeZone, "local", time.Local.String(), "error", err)
+		return time.Local
+	}
+	slog.Debug("using the city's time zone", "city", c.Name, "time_zone", c.TimeZone)
+	return loc
+}
+
+// GetSunriseSunset returns the sunrise and sunset on the calendar day of at.
+// If c's Horizon is Effective, they are its EffectiveSunriseSunset.
 func (c *CityInfo) GetSunriseSunset(at time.Time) (sunrise time.Time, sunset time.Time, err error) {
-	_, secondsOffset := at.Zone()
-	hoursOffset := float64(secondsOffset) / 60 / 60
-	p := sunrisesunset.Parameters{
-		Latitude:  c.Latitude,
-		Longitude: c.Longitude,
-		UtcOffset: hoursOffset,
-		Date:      at,
+	if c.Horizon.Effective && c.Obstructed() {
+		return c.EffectiveSunriseSunset(at)
+	}
+	sunrise, riseOk := c.EventTime(Sunrise, at)
+	sunset, setOk := c.EventTime(Sunset, at)
+	if !riseOk || !setOk {
+		return time.Time{}, time.Time{}, fmt.Errorf("the sun does not rise and set in %s on %s", c.Name, at.Format("2006-01-02"))
 	}
+	return sunrise, sunset, nil
+}
+
+// A Period is the day or night a moment falls in, and how far through it
+// the moment is.
+type Period struct {
+	City    *CityInfo
+	At      time.Time
+	Sunrise time.Time
+	Sunset  time.Time
 
-	return p.GetSunriseSunset()
+	Day      bool // Between sunrise and sunset
+	Start    time.Time
+	Duration time.Duration
 }
 
-func GetPeriodPercent(c *CityInfo, at time.Time, debug bool) (string, error) {
-	if debug {
-		fmt.Printf("City: %#v\n", c)
-	}
+// GetPeriod finds the period at, logging the intermediate results at debug
+// level.
+func GetPeriod(c *CityInfo, at time.Time) (*Period, error) {
+	slog.Debug("finding period", "city", c, "at", at)
+	c.logSunAt(at)
 
 	sunrise, sunset, err := c.GetSunriseSunset(at)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	p := &Period{City: c, At: at, Sunrise: sunrise, Sunset: sunset}
 
-	if debug {
-		fmt.Println("Sunrise:", sunrise.Format("15:04:05")) // Sunrise: 06:11:44
-		fmt.Println("Sunset:", sunset.Format("15:04:05"))   // Sunset: 18:14:27
-		fmt.Println("Length of apparent solar time in mean solar time:", sunset.Sub(sunrise))
-		fmt.Println("Solar noon:", sunrise.Add(sunset.Sub(sunrise)/2).Format("15:04:05"))
+	if debugEnabled() {
+		slog.Debug("horizon",
+			"dip", c.Horizon.dip(c.Elevation),
+			"sunrise_altitude", c.EventAltitude(Sunrise, at),
+			"obstructed", c.Obstructed(),
+		)
+		noon := c.SolarNoon(at)
+		slog.Debug("sunrise and sunset",
+			"sunrise", sunrise,
+			"solar_noon", noon,
+			"sunset", sunset,
+			// Apparent solar time, in mean solar time
+			"day_length", sunset.Sub(sunrise),
+		)
+		c.logSunAt(sunrise)
+		c.logSunAt(noon)
+		c.logSunAt(sunset)
+		dl := c.DayLength(at)
+		slog.Debug("day length",
+			"change_since_yesterday", dl.ChangeSinceYesterday,
+			"last_solstice", dl.LastSolstice.String(),
+			"change_since_solstice", dl.ChangeSinceSolstice,
+			"next_solstice", dl.NextSolstice.String(),
+			"next_solstice_time", dl.NextSolsticeTime,
+			"next_equinox", dl.NextEquinox.String(),
+			"next_equinox_time", dl.NextEquinoxTime,
+		)
 	}
 
 	dayDuration := sunset.Sub(sunrise)
-	if at.After(sunrise) && at.Before(sunset) {
-		hasPassed := at.Sub(sunrise)
-		if debug {
-			fmt.Println("Time passed since sunrise:", hasPassed)
-		}
-		fractionPassed := hasPassed.Seconds() / dayDuration.Seconds()
-		return fmt.Sprintf("%.0f%% ☉", fractionPassed*100), nil
+	if !at.Before(sunrise) && at.Before(sunset) {
+		p.Day = true
+		p.Start = sunrise
+		p.Duration = dayDuration
+		slog.Debug("day", "start", p.Start, "duration", p.Duration, "elapsed", p.Elapsed())
+		return p, nil
 	}
 
 	// https://glossary.ametsoc.org/wiki/Mean_solar_day
-	meanDayDuration, err := time.ParseDuration("86400s")
-	if err != nil {
-		return "", err
+	meanDayDuration := 86400 * time.Second
+	p.Duration = meanDayDuration - dayDuration
+	if at.Before(sunrise) {
+		p.Start = sunrise.Add(-p.Duration)
+	} else {
+		p.Start = sunset
 	}
-	nightDuration := meanDayDuration - dayDuration
+	slog.Debug("night", "start", p.Start, "duration", p.Duration, "elapsed", p.Elapsed())
+	return p, nil
+}
 
-	if at.Before(sunrise) {
-		nightEnd := sunrise
-		nightStart := nightEnd.Add(-nightDuration)
-		hasPassedNight := at.Sub(nightStart)
-		fractionPassedNight := hasPassedNight.Seconds() / nightDuration.Seconds()
-		if debug {
-			fmt.Println("Time passed since sunset:", hasPassedNight)
-		}
-		return fmt.Sprintf("%.0f%% ☾", fractionPassedNight*100), nil
+// Elapsed returns how long it has been since the period started.
+func (p *Period) Elapsed() time.Duration {
+	return p.At.Sub(p.Start)
+}
+
+// Fraction returns how far through the period p.At is, from 0 to 1.
+func (p *Period) Fraction() float64 {
+	return p.Elapsed().Seconds() / p.Duration.Seconds()
+}
+
+func (p *Period) Percent() float64 {
+	return p.Fraction() * 100
+}
+
+// Phase returns the name of the part of the day p.At is in: "day", "night",
+// "golden hour", or "blue hour".
+func (p *Period) Phase() string {
+	// Event altitudes are geometric, so compare against the unrefracted elevation
+	elevation := p.City.geometricElevation(p.At)
+	switch {
+	case elevation >= GoldenHourLow && elevation < GoldenHourHigh:
+		return "golden hour"
+	case elevation >= BlueHourLow && elevation < GoldenHourLow:
+		return "blue hour"
+	case p.Day:
+		return "day"
+	default:
+		return "night"
 	}
+}
 
-	if at.After(sunset) {
-		nightStart := sunset
-		hasPassedNight := at.Sub(nightStart)
-		fractionPassedNight := hasPassedNight.Seconds() / nightDuration.Seconds()
-		if debug {
-			fmt.Println("Time passed since sunset:", hasPassedNight)
-		}
-		return fmt.Sprintf("%.0f%% ☾", fractionPassedNight*100), nil
+func (p *Period) Symbol() string {
+	switch p.Phase() {
+	case "golden hour":
+		return "☼"
+	case "blue hour":
+		return "◐"
+	case "day":
+		return "☉"
+	default:
+		return "☾"
 	}
+}
 
-	panic("This should never happen")
+func (p *Period) String() string {
+	return fmt.Sprintf("%.0f%% %s", p.Percent(), p.Symbol())
 }
 
+func GetPeriodPercent(c *CityInfo, at time.Time) (string, error) {
+	p, err := GetPeriod(c, at)
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+// A Dataset opens a table of cities: one per line, with tab-separated name,
+// country code, FIPS code, latitude, longitude, and optionally time zone and
+// elevation, as scripts/makecsv.sh generates.
+type Dataset struct {
+	Name string // Where it's from, for logs
+	Open func() (io.ReadCloser, error)
+}
+
+// EmbeddedDataset is the dataset built into sundial.
+var EmbeddedDataset = Dataset{
+	Name: "embedded",
+	Open: func() (io.ReadCloser, error) {
+		return fs.Open("cities.csv")
+	},
+}
+
+// DatasetFile returns the dataset in the file at path.
+func DatasetFile(path string) Dataset {
+	return Dataset{
+		Name: path,
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+}
+
+// FindCities finds cities in the embedded dataset.
 func FindCities(name, countryCode, fipsCode string, byPrefix bool) ([]*CityInfo, error) {
-	file, err := fs.Open("cities.csv")
+	return EmbeddedDataset.FindCities(name, countryCode, fipsCode, byPrefix)
+}
+
+// FindCities finds the cities with the given name, country code, and FIPS
+// code, or if byPrefix, those starting with them.
+func (d Dataset) FindCities(name, countryCode, fipsCode string, byPrefix bool) ([]*CityInfo, error) {
+	file, err := d.Open()
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
+
+	// The dataset has no version of its own, so log a hash of it instead
+	var in io.Reader = file
+	var sum hash.Hash
+	if debugEnabled() {
+		sum = sha256.New()
+		in = io.TeeReader(file, sum)
+	}
+	rows := 0
 
-	r := csv.NewReader(file)
+	r := csv.NewReader(in)
 	r.Comma = '\t'
 	r.ReuseRecord = true
 
@@ -119,6 +267,7 @@ func FindCities(name, countryCode, fipsCode string, byPrefix bool) ([]*CityInfo,
 		} else if err != nil {
 			return nil, err
 		}
+		rows++
 
 		if byPrefix && countryCode == "" && fipsCode == "" {
 			if !strings.HasPrefix(record[0], name) {
@@ -158,7 +307,30 @@ func FindCities(name, countryCode, fipsCode string, byPrefix bool) ([]*CityInfo,
 		if err != nil {
 			return nil, err
 		}
-		cities = append(cities, &CityInfo{Name: record[0], CountryCode: record[1], FipsCode: record[2], Latitude: lat, Longitude: long})
+		city := &CityInfo{Name: record[0], CountryCode: record[1], FipsCode: record[2], Latitude: lat, Longitude: long}
+		// Older datasets were generated without time zones and elevations
+		if len(record) > 5 {
+			city.TimeZone = record[5]
+		}
+		if len(record) > 6 && record[6] != "" {
+			city.Elevation, err = strconv.ParseFloat(record[6], 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cities = append(cities, city)
+	}
+	if sum != nil {
+		slog.Debug("searched dataset",
+			"dataset", d.Name,
+			"sha256", hex.EncodeToString(sum.Sum(nil))[:12],
+			"rows", rows,
+			"name", name,
+			"country_code", countryCode,
+			"fips_code", fipsCode,
+			"by_prefix", byPrefix,
+			"matches", len(cities),
+		)
 	}
 	return cities, nil
 }
@@ -171,8 +343,16 @@ func (e *NarrowingError) Error() string {
 	return "could not narrow between cities"
 }
 
+// FindCity finds the one city in the embedded dataset with the given name,
+// country code, and FIPS code.
 func FindCity(name, countryCode, fipsCode string) (*CityInfo, error) {
-	cities, err := FindCities(name, countryCode, fipsCode, false)
+	return EmbeddedDataset.FindCity(name, countryCode, fipsCode)
+}
+
+// FindCity finds the one city with the given name, country code, and FIPS
+// code. If there's more than one, it returns a *NarrowingError.
+func (d Dataset) FindCity(name, countryCode, fipsCode string) (*CityInfo, error) {
+	cities, err := d.FindCities(name, countryCode, fipsCode, false)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Cycle is the span between two equinoxes or solstices that a moment falls
+// in, and how far through it the moment is. It is the year and season
+// counterpart of Period.
+type Cycle struct {
+	City       *CityInfo
+	At         time.Time
+	StartEvent SeasonEvent
+	Start      time.Time
+	EndEvent   SeasonEvent
+	End        time.Time
+}
+
+// The seasons starting at each SeasonEvent, in each hemisphere.
+var seasonNames = map[SeasonEvent]struct {
+	north, south string
+}{
+	MarchEquinox:     {"spring", "autumn"},
+	JuneSolstice:     {"summer", "winter"},
+	SeptemberEquinox: {"autumn", "spring"},
+	DecemberSolstice: {"winter", "summer"},
+}
+
+var seasonSymbols = map[string]string{
+	"spring": "❀",
+	"summer": "☀",
+	"autumn": "❦",
+	"winter": "❄",
+}
+
+// GetYear finds the tropical year at falls in, from one winter solstice to
+// the next. Winter is in December in the northern hemisphere and in June in
+// the southern.
+func GetYear(c *CityInfo, at time.Time) *Cycle {
+	winter := DecemberSolstice
+	if c.Latitude < 0 {
+		winter = JuneSolstice
+	}
+	cycle := &Cycle{City: c, At: at, StartEvent: winter, EndEvent: winter}
+	_, cycle.Start = PreviousSeasonEvent(at, winter)
+	_, cycle.End = NextSeasonEvent(at, winter)
+	return cycle
+}
+
+// GetSeason finds the astronomical season at falls in, from an equinox to a
+// solstice or a solstice to an equinox.
+func GetSeason(c *CityInfo, at time.Time) *Cycle {
+	all := []SeasonEvent{MarchEquinox, JuneSolstice, SeptemberEquinox, DecemberSolstice}
+	cycle := &Cycle{City: c, At: at}
+	cycle.StartEvent, cycle.Start = PreviousSeasonEvent(at, all...)
+	cycle.EndEvent, cycle.End = NextSeasonEvent(at, all...)
+	return cycle
+}
+
+// Fraction returns how far through the cycle c.At is, from 0 to 1.
+func (c *Cycle) Fraction() float64 {
+	return c.At.Sub(c.Start).Seconds() / c.End.Sub(c.Start).Seconds()
+}
+
+func (c *Cycle) Percent() float64 {
+	return c.Fraction() * 100
+}
+
+// Phase returns the name of the season c.At is in, for c.City's hemisphere.
+func (c *Cycle) Phase() string {
+	event, _ := PreviousSeasonEvent(c.At, MarchEquinox, JuneSolstice, SeptemberEquinox, DecemberSolstice)
+	if c.City.Latitude < 0 {
+		return seasonNames[event].south
+	}
+	return seasonNames[event].north
+}
+
+func (c *Cycle) Symbol() string {
+	return seasonSymbols[c.Phase()]
+}
+
+func (c *Cycle) String() string {
+	return fmt.Sprintf("%.0f%% %s", c.Percent(), c.Symbol())
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// Refraction is how to account for the atmosphere bending sunlight, which
+// makes the sun look higher in the sky than it is.
+type Refraction int
+
+const (
+	// StandardRefraction is the almanac convention: 34' at the horizon, the
+	// NOAA approximation above it.
+	StandardRefraction Refraction = iota
+	// ComputedRefraction uses Horizon's Pressure and Temperature, with
+	// Bennett's formula at the horizon and Sæmundsson's above it.
+	ComputedRefraction
+	// NoRefraction gives the geometric position of the sun, as if there were
+	// no atmosphere.
+	NoRefraction
+)
+
+var refractionNames = []string{"standard", "computed", "none"}
+
+func (r Refraction) String() string {
+	if r < 0 || int(r) >= len(refractionNames) {
+		return fmt.Sprintf("Refraction(%d)", int(r))
+	}
+	return refractionNames[r]
+}
+
+func ParseRefraction(s string) (Refraction, error) {
+	for i, name := range refractionNames {
+		if s == name {
+			return Refraction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown refraction '%s': expected one of standard, computed, or none", s)
+}
+
+const (
+	// Conditions the standard refraction is for.
+	StandardPressure    = 1010.0 // Millibars
+	StandardTemperature = 10.0   // Degrees Celsius
+
+	standardHorizonRefraction = 34.0 / 60
+)
+
+// Horizon holds the corrections to make to the sea-level horizon that sunrise
+// and sunset are calculated against, and to the apparent position of the sun.
+type Horizon struct {
+	// Dip lowers the horizon by how far the city is above sea level. From high
+	// up you can see further around the curve of the earth, so the sun rises
+	// earlier and sets later.
+	Dip bool
+
+	Refraction Refraction
+	// Used by ComputedRefraction. A Pressure of 0 means StandardPressure.
+	Pressure    float64 // Millibars
+	Temperature float64 // Degrees Celsius
+
+	// The skyline, if there are buildings or mountains in the way. Nil for a
+	// flat horizon.
+	Profile Profile
+	// If set, the sun can only reach you from these directions, as through a
+	// window.
+	Window *AzimuthRange
+	// Whether sunrise and sunset are when direct sun first reaches you and
+	// last leaves, over the Profile and through the Window, instead of when
+	// the sun crosses the horizon.
+	Effective bool
+}
+
+// dip returns how far below the sea-level horizon the visible horizon is, in
+// degrees, for an observer elevation meters up. This is the standard
+// navigator's formula, 1.76' per square root meter, which includes
+// terrestrial refraction.
+func (h Horizon) dip(elevation float64) float64 {
+	if !h.Dip || elevation <= 0 {
+		return 0
+	}
+	return 1.76 / 60 * math.Sqrt(elevation)
+}
+
+// correct returns the geometric altitude of the sun when it appears to be at
+// altitude on the visible horizon, given an altitude that assumes a sea-level
+// horizon and standard refraction.
+func (h Horizon) correct(altitude, elevation float64) float64 {
+	dip := h.dip(elevation)
+	switch h.Refraction {
+	case ComputedRefraction:
+		// Bennett's formula, from the apparent altitude of the visible horizon
+		refraction := 1 / math.Tan(deg2rad(-dip+7.31/(-dip+4.4))) / 60
+		return altitude + standardHorizonRefraction - refraction*h.conditions() - dip
+	case NoRefraction:
+		return altitude + standardHorizonRefraction - dip
+	}
+	return altitude - dip
+}
+
+// refract returns how many degrees the atmosphere raises the sun when its
+// geometric elevation is elevation.
+func (h Horizon) refract(elevation float64) float64 {
+	switch h.Refraction {
+	case ComputedRefraction:
+		// Sæmundsson's formula falls apart well below the horizon, where
+		// refraction can't be seen anyway.
+		if elevation < -1 {
+			return 0
+		}
+		return 1.02 / math.Tan(deg2rad(elevation+10.3/(elevation+5.11))) / 60 * h.conditions()
+	case NoRefraction:
+		return 0
+	}
+	return refraction(elevation)
+}
+
+// conditions scales refraction for the air's pressure and temperature.
+func (h Horizon) conditions() float64 {
+	pressure := h.Pressure
+	if pressure == 0 {
+		pressure = StandardPressure
+	}
+	return pressure / 1010 * 283 / (273 + h.Temperature)
+}
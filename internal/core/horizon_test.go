@@ -0,0 +1,27 @@
+package core
+
+import "testing"
+
+func TestRefractionString(t *testing.T) {
+	for _, name := range refractionNames {
+		r, err := ParseRefraction(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.String() != name {
+			t.Errorf("ParseRefraction(%q).String() = %q", name, r)
+		}
+	}
+	if _, err := ParseRefraction("bennett"); err == nil {
+		t.Errorf("ParseRefraction(%q) succeeded, want an error", "bennett")
+	}
+	if got := Refraction(-1).String(); got != "Refraction(-1)" {
+		t.Errorf("Refraction(-1).String() = %q", got)
+	}
+	if got := Refraction(3).String(); got != "Refraction(3)" {
+		t.Errorf("Refraction(3).String() = %q", got)
+	}
+	if got := Precision(7).String(); got != "Precision(7)" {
+		t.Errorf("Precision(7).String() = %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// Seasonal (or temporal) hours split the day and the night into twelve equal
+// hours each, so that summer daytime hours are longer than winter ones. The
+// Japanese wadokei splits them into six koku each instead, named for the
+// zodiac animals and counted by the number of bells rung.
+
+var (
+	dayKoku = []koku{
+		{"Hare", "六つ"},
+		{"Dragon", "五つ"},
+		{"Snake", "四つ"},
+		{"Horse", "九つ"},
+		{"Sheep", "八つ"},
+		{"Monkey", "七つ"},
+	}
+	nightKoku = []koku{
+		{"Rooster", "六つ"},
+		{"Dog", "五つ"},
+		{"Boar", "四つ"},
+		{"Rat", "九つ"},
+		{"Ox", "八つ"},
+		{"Tiger", "七つ"},
+	}
+)
+
+type koku struct {
+	animal string
+	bells  string
+}
+
+// Hour returns which of the period's twelve seasonal hours p.At is in,
+// from 1 to 12.
+func (p *Period) Hour() int {
+	return int(math.Min(math.Floor(p.Fraction()*12), 11)) + 1
+}
+
+// HourFraction returns how far through its seasonal hour p.At is, from 0 to 1.
+func (p *Period) HourFraction() float64 {
+	return p.Fraction()*12 - float64(p.Hour()-1)
+}
+
+// Koku returns which of the period's six koku p.At is in, from 1 to 6.
+func (p *Period) Koku() int {
+	return int(math.Min(math.Floor(p.Fraction()*6), 5)) + 1
+}
+
+// KokuFraction returns how far through its koku p.At is, from 0 to 1.
+func (p *Period) KokuFraction() float64 {
+	return p.Fraction()*6 - float64(p.Koku()-1)
+}
+
+// KokuName returns the zodiac animal of p.At's koku, e.g. "Dragon".
+func (p *Period) KokuName() string {
+	return p.koku().animal
+}
+
+// KokuBells returns the bell count of p.At's koku, e.g. "五つ".
+func (p *Period) KokuBells() string {
+	return p.koku().bells
+}
+
+func (p *Period) koku() koku {
+	if p.Day {
+		return dayKoku[p.Koku()-1]
+	}
+	return nightKoku[p.Koku()-1]
+}
+
+// SeasonalHours prints a Period as a seasonal hour,
+// e.g. "3rd hour of day, 40% through".
+type SeasonalHours struct{ *Period }
+
+func (h SeasonalHours) String() string {
+	period := "night"
+	if h.Day {
+		period = "day"
+	}
+	return fmt.Sprintf("%s hour of %s, %.0f%% through", ordinal(h.Hour()), period, h.HourFraction()*100)
+}
+
+// KokuHours prints a Period as a wadokei koku,
+// e.g. "hour of the Dragon (五つ), 40% through".
+type KokuHours struct{ *Period }
+
+func (h KokuHours) String() string {
+	return fmt.Sprintf("hour of the %s (%s), %.0f%% through", h.KokuName(), h.KokuBells(), h.KokuFraction()*100)
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
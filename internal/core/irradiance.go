@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Clear-sky irradiance uses the Ineichen and Perez model, from "A new airmass
+// independent formulation for the Linke turbidity coefficient", Solar Energy
+// 73 (2002), as implemented in pvlib. It needs the Linke turbidity of the air:
+// about 2 for very clean air, 3 for typical rural air, and 4 to 6 for cities
+// and humid summers.
+
+const (
+	// DefaultLinkeTurbidity is for moderately clear, rural air.
+	DefaultLinkeTurbidity = 3.0
+	// DefaultAlbedo is the fraction of light the ground reflects, for grass.
+	DefaultAlbedo = 0.2
+
+	solarConstant = 1367.7 // W/m²
+)
+
+// Irradiance is how much sunlight reaches the ground under a clear sky, in
+// W/m².
+type Irradiance struct {
+	At time.Time
+	// Global horizontal: everything falling on level ground
+	GHI float64
+	// Direct normal: straight from the sun, on a surface facing it
+	DNI float64
+	// Diffuse horizontal: scattered by the sky, on level ground
+	DHI float64
+	// On the Panel the irradiance was computed for
+	Panel float64
+}
+
+func (i Irradiance) String() string {
+	return fmt.Sprintf("GHI %.0f W/m², DNI %.0f W/m², DHI %.0f W/m², panel %.0f W/m²", i.GHI, i.DNI, i.DHI, i.Panel)
+}
+
+// A Panel is a flat surface, like a solar panel.
+type Panel struct {
+	Tilt    float64 // Degrees from horizontal
+	Azimuth float64 // Degrees clockwise from north that it faces
+	Albedo  float64 // Of the ground in front of it
+}
+
+// ClearSkyIrradiance returns the irradiance at the given instant under a
+// clear sky with the given Linke turbidity, and on panel.
+func (c *CityInfo) ClearSkyIrradiance(at time.Time, linke float64, panel Panel) Irradiance {
+	irr := Irradiance{At: at}
+	pos := c.SolarPosition(at)
+	if pos.Elevation <= 0 {
+		return irr
+	}
+	zenith := 90 - pos.Elevation
+	cosZenith := math.Cos(deg2rad(zenith))
+
+	// Extraterrestrial irradiance changes with the distance to the sun
+	i0 := solarConstant * (1 + 0.033*math.Cos(2*math.Pi*float64(at.YearDay())/365))
+
+	// Kasten and Young's relative airmass, scaled by the air pressure
+	h := math.Max(c.Elevation, 0)
+	relativeAirmass := 1 / (cosZenith + 0.50572*math.Pow(96.07995-zenith, -1.6364))
+	airmass := relativeAirmass * math.Pow(1-2.25577e-5*h, 5.25588)
+
+	fh1 := math.Exp(-h / 8000)
+	fh2 := math.Exp(-h / 1250)
+	cg1 := 5.09e-5*h + 0.868
+	cg2 := 3.92e-5*h + 0.0387
+
+	irr.GHI = math.Max(cg1*i0*cosZenith*math.Exp(-cg2*airmass*(fh1+fh2*(linke-1))), 0)
+	b := 0.664 + 0.163/fh1
+	dni := b * i0 * math.Exp(-0.09*airmass*(linke-1))
+	// Limit the direct light to what the global total allows
+	dniLimit := irr.GHI * math.Max((1-(0.1-0.2*math.Exp(-linke))/(0.1+0.882/fh1))/cosZenith, 0)
+	irr.DNI = math.Max(math.Min(dni, dniLimit), 0)
+	irr.DHI = irr.GHI - irr.DNI*cosZenith
+
+	// Direct light by the angle of incidence, with an isotropic sky and
+	// light reflected off the ground
+	tilt := deg2rad(panel.Tilt)
+	cosIncidence := cosZenith*math.Cos(tilt) +
+		math.Sin(deg2rad(zenith))*math.Sin(tilt)*math.Cos(deg2rad(pos.Azimuth-panel.Azimuth))
+	irr.Panel = irr.DNI*math.Max(cosIncidence, 0) +
+		irr.DHI*(1+math.Cos(tilt))/2 +
+		irr.GHI*panel.Albedo*(1-math.Cos(tilt))/2
+	return irr
+}
+
+// Insolation is how much sunlight reaches the ground over a day under a
+// clear sky, in kWh/m².
+type Insolation struct {
+	Day   time.Time
+	GHI   float64
+	DNI   float64
+	DHI   float64
+	Panel float64
+}
+
+// ClearSkyInsolation returns the insolation over the calendar day of day, in
+// day's location, summed every five minutes.
+func (c *CityInfo) ClearSkyInsolation(day time.Time, linke float64, panel Panel) Insolation {
+	const step = 5 * time.Minute
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, day.Location())
+
+	ins := Insolation{Day: start}
+	hours := step.Hours()
+	// Sample the middle of each step
+	for t := start.Add(step / 2); t.Before(end); t = t.Add(step) {
+		irr := c.ClearSkyIrradiance(t, linke, panel)
+		ins.GHI += irr.GHI * hours / 1000
+		ins.DNI += irr.DNI * hours / 1000
+		ins.DHI += irr.DHI * hours / 1000
+		ins.Panel += irr.Panel * hours / 1000
+	}
+	return ins
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Diagnostics go to the default slog logger at debug level, so they're
+// silent unless whoever is running sundial turns them on.
+
+// debugEnabled reports whether debug logs are written, so quantities that
+// are only logged needn't be computed otherwise.
+func debugEnabled() bool {
+	return slog.Default().Enabled(context.Background(), slog.LevelDebug)
+}
+
+// LogValue logs c as a group of its fields, including those not from the
+// dataset.
+func (c *CityInfo) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("name", c.Name),
+		slog.String("country_code", c.CountryCode),
+		slog.String("fips_code", c.FipsCode),
+		slog.Float64("latitude", c.Latitude),
+		slog.Float64("longitude", c.Longitude),
+		slog.String("time_zone", c.TimeZone),
+		slog.Float64("elevation", c.Elevation),
+		slog.Bool("dip", c.Horizon.Dip),
+		slog.String("refraction", c.Horizon.Refraction.String()),
+		slog.String("precision", c.Precision.String()),
+	}
+	if c.Horizon.Refraction == ComputedRefraction {
+		attrs = append(attrs, slog.Float64("pressure", c.Horizon.Pressure), slog.Float64("temperature", c.Horizon.Temperature))
+	}
+	if c.Horizon.Profile != nil {
+		attrs = append(attrs, slog.Int("profile_points", len(c.Horizon.Profile)))
+	}
+	if c.Horizon.Window != nil {
+		attrs = append(attrs, slog.String("window", c.Horizon.Window.String()))
+	}
+	if c.Horizon.Effective {
+		attrs = append(attrs, slog.Bool("effective", true))
+	}
+	if len(c.Events) > 0 {
+		names := make([]string, len(c.Events))
+		for i, e := range c.Events {
+			names[i] = e.Name
+		}
+		attrs = append(attrs, slog.Any("events", names))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// logSunAt logs every intermediate quantity in finding the sun's position in
+// c's sky at at.
+func (c *CityInfo) logSunAt(at time.Time) {
+	if !debugEnabled() {
+		return
+	}
+	sun := c.sun(at)
+	geometric := c.geometricElevation(at)
+	position := c.SolarPosition(at)
+	slog.Debug("sun",
+		"at", at,
+		"julian_day", julianDay(at),
+		"julian_century", julianCentury(at),
+		"delta_t_seconds", deltaT(at),
+		"ecliptic_longitude", sun.longitude,
+		"declination", sun.declination,
+		"equation_of_time_minutes", sun.eqTime,
+		"hour_angle", c.hourAngle(at),
+		"geometric_elevation", geometric,
+		"refraction", position.Elevation-geometric,
+		"elevation", position.Elevation,
+		"azimuth", position.Azimuth,
+	)
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A Method is a named set of extra events, as used to calculate prayer times
+// and zmanim. Dhuhr is solar noon, Maghrib is sunset unless the method says
+// otherwise, and Asr uses a shadow factor of 1; the hanafi method adds the
+// later asr_hanafi.
+type Method struct {
+	Name        string
+	Description string
+	Events      []Event
+}
+
+// islamicMethod returns the events for a method that puts Fajr and Isha at
+// the given depressions of the sun below the horizon.
+func islamicMethod(name, description string, fajr, isha float64) Method {
+	return Method{
+		Name:        name,
+		Description: description,
+		Events: []Event{
+			{Name: "fajr", Altitude: -fajr, Rising: true},
+			{Name: "asr", ShadowFactor: 1},
+			{Name: "isha", Altitude: -isha},
+		},
+	}
+}
+
+// Methods is every built in Method.
+var Methods = []Method{
+	islamicMethod("mwl", "Muslim World League: Fajr 18°, Isha 17°", 18, 17),
+	islamicMethod("isna", "Islamic Society of North America: Fajr 15°, Isha 15°", 15, 15),
+	islamicMethod("egypt", "Egyptian General Authority of Survey: Fajr 19.5°, Isha 17.5°", 19.5, 17.5),
+	islamicMethod("karachi", "University of Islamic Sciences, Karachi: Fajr 18°, Isha 18°", 18, 18),
+	func() Method {
+		m := islamicMethod("tehran", "Institute of Geophysics, Tehran: Fajr 17.7°, Maghrib 4.5°, Isha 14°", 17.7, 14)
+		m.Events = append(m.Events, Event{Name: "maghrib", Altitude: -4.5})
+		return m
+	}(),
+	func() Method {
+		m := islamicMethod("jafari", "Shia Ithna Ashari, Leva Institute: Fajr 16°, Maghrib 4°, Isha 14°", 16, 14)
+		m.Events = append(m.Events, Event{Name: "maghrib", Altitude: -4})
+		return m
+	}(),
+	{
+		Name:        "hanafi",
+		Description: "Asr when shadows have grown by twice their length",
+		Events:      []Event{{Name: "asr_hanafi", ShadowFactor: 2}},
+	},
+	{
+		Name:        "zmanim",
+		Description: "Alot hashachar 16.1°, misheyakir 11.5°, tzeit hakochavim 8.5°",
+		Events: []Event{
+			{Name: "alot_hashachar", Altitude: -16.1, Rising: true},
+			{Name: "misheyakir", Altitude: -11.5, Rising: true},
+			{Name: "tzeit_hakochavim", Altitude: -8.5},
+		},
+	},
+}
+
+// MethodNames returns the names of every built in Method.
+func MethodNames() []string {
+	var names []string
+	for _, m := range Methods {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// FindMethod returns the built in Method called name.
+func FindMethod(name string) (Method, error) {
+	for _, m := range Methods {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return Method{}, fmt.Errorf("unknown method '%s': expected one of %s", name, strings.Join(MethodNames(), ", "))
+}
+
+// ParseEvent parses a custom event, given as "NAME:ALTITUDE:rising",
+// "NAME:ALTITUDE:setting", or "NAME:shadow:FACTOR". Altitudes are degrees
+// above the horizon, so depressions are negative. Names are letters, digits,
+// and underscores, so they can be schedule anchors.
+func ParseEvent(s string) (Event, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return Event{}, fmt.Errorf("invalid event '%s': expected NAME:ALTITUDE:rising, NAME:ALTITUDE:setting, or NAME:shadow:FACTOR", s)
+	}
+	for _, r := range parts[0] {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return Event{}, fmt.Errorf("invalid event '%s': names can only have letters, digits, and underscores", s)
+		}
+	}
+	e := Event{Name: parts[0]}
+
+	if parts[1] == "shadow" {
+		factor, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil || factor <= 0 {
+			return Event{}, fmt.Errorf("invalid event '%s': shadow factor must be a positive number", s)
+		}
+		e.ShadowFactor = factor
+		return e, nil
+	}
+
+	altitude, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || altitude <= -90 || altitude >= 90 {
+		return Event{}, fmt.Errorf("invalid event '%s': altitude must be a number of degrees between -90 and 90", s)
+	}
+	e.Altitude = altitude
+	switch parts[2] {
+	case "rising":
+		e.Rising = true
+	case "setting":
+	default:
+		return Event{}, fmt.Errorf("invalid event '%s': expected rising or setting, not '%s'", s, parts[2])
+	}
+	return e, nil
+}
+
+// AllEvents returns Events followed by c's own Events.
+func (c *CityInfo) AllEvents() []Event {
+	return append(append([]Event{}, Events...), c.Events...)
+}
+
+// FindEvent returns the event called name from c's AllEvents. Later events
+// win, so a city's own events can replace the built in ones.
+func (c *CityInfo) FindEvent(name string) (Event, error) {
+	events := c.AllEvents()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Name == name {
+			return events[i], nil
+		}
+	}
+	return Event{}, fmt.Errorf("unknown event '%s'", name)
+}
+
+// Event returns when the event called name happens on the calendar day of
+// p.At. Templates can use it like {{.Event "fajr"}}.
+func (p *Period) Event(name string) (time.Time, error) {
+	e, err := p.City.FindEvent(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := p.City.EventTime(e, p.At)
+	if !ok {
+		return time.Time{}, fmt.Errorf("the sun does not reach %s in %s on %s", name, p.City.Name, p.At.Format("2006-01-02"))
+	}
+	return t, nil
+}
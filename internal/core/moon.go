@@ -0,0 +1,245 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Lunar calculations use the largest terms of the series in chapter 47 of
+// Jean Meeus' Astronomical Algorithms, which are good to a few hundredths of
+// a degree. That's a couple of minutes in moonrise and moonset.
+
+const (
+	// Mean length of a lunation, in days.
+	synodicMonth = 29.530588853
+	// Mean distance to the sun, in km. The phase angle hardly depends on it.
+	sunDistance = 149597870.7
+	earthRadius = 6378.14
+)
+
+var moonPhases = []struct {
+	name   string
+	symbol string
+}{
+	{"new moon", "🌑"},
+	{"waxing crescent", "🌒"},
+	{"first quarter", "🌓"},
+	{"waxing gibbous", "🌔"},
+	{"full moon", "🌕"},
+	{"waning gibbous", "🌖"},
+	{"last quarter", "🌗"},
+	{"waning crescent", "🌘"},
+}
+
+// moonCoordinates describes where the moon is at an instant.
+type moonCoordinates struct {
+	longitude float64 // Ecliptic, degrees
+	latitude  float64 // Ecliptic, degrees
+	distance  float64 // km
+}
+
+func moonAt(at time.Time) moonCoordinates {
+	jc := julianCentury(at)
+
+	meanLong := 218.3164477 + 481267.88123421*jc
+	elong := deg2rad(297.8501921 + 445267.1114034*jc)
+	sunAnom := deg2rad(357.5291092 + 35999.0502909*jc)
+	moonAnom := deg2rad(134.9633964 + 477198.8675055*jc)
+	node := deg2rad(93.2720950 + 483202.0175233*jc)
+
+	longitude := meanLong +
+		6.288774*math.Sin(moonAnom) +
+		1.274027*math.Sin(2*elong-moonAnom) +
+		0.658314*math.Sin(2*elong) +
+		0.213618*math.Sin(2*moonAnom) -
+		0.185116*math.Sin(sunAnom) -
+		0.114332*math.Sin(2*node) +
+		0.058793*math.Sin(2*elong-2*moonAnom) +
+		0.057066*math.Sin(2*elong-sunAnom-moonAnom) +
+		0.053322*math.Sin(2*elong+moonAnom) +
+		0.045758*math.Sin(2*elong-sunAnom) -
+		0.040923*math.Sin(sunAnom-moonAnom) -
+		0.034720*math.Sin(elong) -
+		0.030383*math.Sin(sunAnom+moonAnom)
+
+	latitude := 5.128122*math.Sin(node) +
+		0.280602*math.Sin(moonAnom+node) +
+		0.277693*math.Sin(moonAnom-node) +
+		0.173237*math.Sin(2*elong-node) +
+		0.055413*math.Sin(2*elong-moonAnom+node) +
+		0.046271*math.Sin(2*elong-moonAnom-node)
+
+	distance := 385000.56 -
+		20905.355*math.Cos(moonAnom) -
+		3699.111*math.Cos(2*elong-moonAnom) -
+		2955.968*math.Cos(2*elong) -
+		569.925*math.Cos(2*moonAnom)
+
+	return moonCoordinates{
+		longitude: math.Mod(math.Mod(longitude, 360)+360, 360),
+		latitude:  latitude,
+		distance:  distance,
+	}
+}
+
+// moonElongation returns how far east of the sun the moon is along the
+// ecliptic, from 0 at new moon to 180 at full moon and up to 360.
+func moonElongation(at time.Time) float64 {
+	return math.Mod(moonAt(at).longitude-sunAt(at).longitude+360, 360)
+}
+
+// newMoonNear returns the new moon closest to guess.
+func newMoonNear(guess time.Time) time.Time {
+	t := guess
+	for i := 0; i < 5; i++ {
+		offset := math.Mod(moonElongation(t)+180, 360) - 180
+		t = t.Add(-time.Duration(offset / 360 * synodicMonth * float64(24*time.Hour)))
+	}
+	return t.Round(time.Second)
+}
+
+// siderealTime returns the local mean sidereal time in degrees.
+func siderealTime(longitude float64, at time.Time) float64 {
+	d := julianDay(at) - 2451545
+	return math.Mod(280.46061837+360.98564736629*d+longitude, 360)
+}
+
+// moonAltitude returns the geocentric altitude of the moon's center, and the
+// altitude it is considered to rise or set at, in degrees.
+func (c *CityInfo) moonAltitude(at time.Time) (altitude, horizon float64) {
+	moon := moonAt(at)
+	jc := julianCentury(at)
+	obliq := deg2rad(23.439291 - 0.0130042*jc)
+	lon, lat := deg2rad(moon.longitude), deg2rad(moon.latitude)
+
+	ra := math.Atan2(math.Sin(lon)*math.Cos(obliq)-math.Tan(lat)*math.Sin(obliq), math.Cos(lon))
+	dec := math.Asin(math.Sin(lat)*math.Cos(obliq) + math.Cos(lat)*math.Sin(obliq)*math.Sin(lon))
+	ha := deg2rad(siderealTime(c.Longitude, at)) - ra
+	phi := deg2rad(c.Latitude)
+
+	altitude = rad2deg(math.Asin(math.Sin(phi)*math.Sin(dec) + math.Cos(phi)*math.Cos(dec)*math.Cos(ha)))
+	// Meeus 15: parallax, semidiameter, and refraction combined
+	parallax := rad2deg(math.Asin(earthRadius / moon.distance))
+	horizon = 0.7275*parallax - 0.5667
+	return altitude, horizon
+}
+
+// moonCrossing finds when the moon rises (or sets) on the calendar day of
+// day, in day's location.
+func (c *CityInfo) moonCrossing(day time.Time, rising bool) (time.Time, bool) {
+	above := func(t time.Time) bool {
+		altitude, horizon := c.moonAltitude(t)
+		return altitude > horizon
+	}
+
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, day.Location())
+	const step = 10 * time.Minute
+	for t := start; t.Before(end); t = t.Add(step) {
+		lo, hi := t, t.Add(step)
+		if above(lo) == above(hi) || above(hi) != rising {
+			continue
+		}
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if above(mid) == rising {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		if hi.Before(end) {
+			return hi.Round(time.Second), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// A Moon is the moon's phase at a moment, and when it rises and sets that day.
+type Moon struct {
+	City         *CityInfo
+	At           time.Time
+	NewMoon      time.Time // Start of the lunation
+	NextNewMoon  time.Time
+	Illumination float64 // Fraction of the disk lit, from 0 to 1
+
+	// Zero if the moon doesn't rise or set on the calendar day of At
+	Rise time.Time
+	Set  time.Time
+}
+
+// GetMoon finds the moon's phase at, logging the intermediate results at
+// debug level.
+func GetMoon(c *CityInfo, at time.Time) *Moon {
+	slog.Debug("finding moon", "city", c, "at", at)
+
+	elongation := moonElongation(at)
+	m := &Moon{City: c, At: at}
+	m.NewMoon = newMoonNear(at.Add(-time.Duration(elongation / 360 * synodicMonth * float64(24*time.Hour))))
+	if m.NewMoon.After(at) {
+		m.NewMoon = newMoonNear(m.NewMoon.Add(-time.Duration(synodicMonth * float64(24*time.Hour))))
+	}
+	m.NextNewMoon = newMoonNear(m.NewMoon.Add(time.Duration(synodicMonth * float64(24*time.Hour))))
+
+	moon := moonAt(at)
+	sun := sunAt(at)
+	psi := math.Acos(math.Cos(deg2rad(moon.latitude)) * math.Cos(deg2rad(moon.longitude-sun.longitude)))
+	phaseAngle := math.Atan2(sunDistance*math.Sin(psi), moon.distance-sunDistance*math.Cos(psi))
+	m.Illumination = (1 + math.Cos(phaseAngle)) / 2
+
+	m.Rise, _ = c.moonCrossing(at, true)
+	m.Set, _ = c.moonCrossing(at, false)
+
+	slog.Debug("moon",
+		"julian_century", julianCentury(at),
+		"delta_t_seconds", deltaT(at),
+		"longitude", moon.longitude,
+		"latitude", moon.latitude,
+		"distance_km", moon.distance,
+		"sun_longitude", sun.longitude,
+		"elongation", elongation,
+		"phase_angle", rad2deg(phaseAngle),
+		"new_moon", m.NewMoon,
+		"next_new_moon", m.NextNewMoon,
+		"age", m.Age(),
+		"illumination", m.Illumination,
+		"moonrise", m.Rise,
+		"moonset", m.Set,
+	)
+	return m
+}
+
+// Age returns how long it has been since the new moon.
+func (m *Moon) Age() time.Duration {
+	return m.At.Sub(m.NewMoon)
+}
+
+// Fraction returns how far through the lunation m.At is, from 0 to 1.
+func (m *Moon) Fraction() float64 {
+	return m.Age().Seconds() / m.NextNewMoon.Sub(m.NewMoon).Seconds()
+}
+
+func (m *Moon) Percent() float64 {
+	return m.Fraction() * 100
+}
+
+// Phase returns the name of the moon's phase, e.g. "waxing crescent".
+func (m *Moon) Phase() string {
+	return moonPhases[m.phaseIndex()].name
+}
+
+func (m *Moon) Symbol() string {
+	return moonPhases[m.phaseIndex()].symbol
+}
+
+func (m *Moon) phaseIndex() int {
+	// Each phase is centered on its eighth of the month
+	return int(math.Floor(m.Fraction()*8+0.5)) % 8
+}
+
+func (m *Moon) String() string {
+	return fmt.Sprintf("%.0f%% %s", m.Percent(), m.Symbol())
+}
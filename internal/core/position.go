@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// A Position is where the sun is in the sky, as seen from a city.
+type Position struct {
+	Elevation   float64 `json:"elevation"`   // Degrees above the horizon, corrected for the city's Refraction
+	Azimuth     float64 `json:"azimuth"`     // Degrees clockwise from north
+	HourAngle   float64 `json:"hour_angle"`  // Degrees west of the meridian; negative before solar noon
+	Declination float64 `json:"declination"` // Degrees north of the celestial equator
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf(`Elevation:   %7.2f°
+Azimuth:     %7.2f°
+Hour angle:  %7.2f°
+Declination: %7.2f°`, p.Elevation, p.Azimuth, p.HourAngle, p.Declination)
+}
+
+// refraction returns how many degrees the atmosphere raises the apparent
+// position of the sun, given its geometric elevation, using the
+// approximation from the NOAA solar calculator.
+func refraction(elevation float64) float64 {
+	tanE := math.Tan(deg2rad(elevation))
+	var arcseconds float64
+	switch {
+	case elevation > 85:
+		return 0
+	case elevation > 5:
+		arcseconds = 58.1/tanE - 0.07/math.Pow(tanE, 3) + 0.000086/math.Pow(tanE, 5)
+	case elevation > -0.575:
+		arcseconds = 1735 + elevation*(-518.2+elevation*(103.4+elevation*(-12.79+elevation*0.711)))
+	default:
+		arcseconds = -20.772 / tanE
+	}
+	return arcseconds / 3600
+}
+
+// horizontal converts the sun's declination and hour angle to its
+// elevation and azimuth in c's sky, in degrees, as if there were no
+// atmosphere.
+func (c *CityInfo) horizontal(declination, hourAngle float64) (elevation, azimuth float64) {
+	phi := deg2rad(c.Latitude)
+	delta := deg2rad(declination)
+	h := deg2rad(hourAngle)
+	cosZenith := math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Cos(h)
+	elevation = 90 - rad2deg(math.Acos(math.Max(-1, math.Min(1, cosZenith))))
+
+	// As in SPA: measured from south, then turned to be from north. Unlike
+	// dividing by the sine of the zenith angle, this holds with the sun
+	// straight overhead.
+	gamma := rad2deg(math.Atan2(math.Sin(h), math.Cos(h)*math.Sin(phi)-math.Tan(delta)*math.Cos(phi)))
+	azimuth = math.Mod(gamma+540, 360)
+	return elevation, azimuth
+}
+
+// geometricElevation returns the sun's elevation in degrees at the given
+// instant, as if there were no atmosphere.
+func (c *CityInfo) geometricElevation(at time.Time) float64 {
+	elevation, _ := c.horizontal(c.sun(at).declination, c.hourAngle(at))
+	return elevation
+}
+
+// SolarPosition returns where the sun is in c's sky at the given instant.
+func (c *CityInfo) SolarPosition(at time.Time) Position {
+	declination := c.sun(at).declination
+	ha := c.hourAngle(at)
+	elevation, azimuth := c.horizontal(declination, ha)
+
+	return Position{
+		Elevation:   elevation + c.Horizon.refract(elevation),
+		Azimuth:     azimuth,
+		HourAngle:   ha,
+		Declination: declination,
+	}
+}
+
+// Position returns where the sun is at p.At.
+func (p *Period) Position() Position {
+	return p.City.SolarPosition(p.At)
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSolarPosition(t *testing.T) {
+	// The SPA paper's example (see spa_test.go), worked with the NOAA
+	// equations. They're less precise than SPA and skip parallax and ΔT,
+	// so allow 0.01°.
+	c := *golden
+	c.Precision = 0
+	p := c.SolarPosition(goldenAt)
+	if zenith := 90 - p.Elevation; math.Abs(zenith-50.11162) > 0.01 {
+		t.Errorf("zenith = %.5f°, want 50.11162° ± 0.01°", zenith)
+	}
+	if math.Abs(p.Azimuth-194.34024) > 0.01 {
+		t.Errorf("azimuth = %.5f°, want 194.34024° ± 0.01°", p.Azimuth)
+	}
+
+	// In the afternoon the sun is west of south
+	p = denver.SolarPosition(time.Date(2024, 6, 21, 16, 0, 0, 0, denverZ))
+	if p.Azimuth < 180 || p.Azimuth > 360 {
+		t.Errorf("SolarPosition in the afternoon = %+v, want an azimuth west of south", p)
+	}
+}
+
+func TestSunAtMeeus(t *testing.T) {
+	// Meeus, Astronomical Algorithms, examples 25.a and 28.a: 1992 October
+	// 13.0 TD. sunAt takes the instant as TD, having no ΔT.
+	s := sunAt(time.Date(1992, 10, 13, 0, 0, 0, 0, time.UTC))
+	if math.Abs(s.declination+7.78507) > 0.001 {
+		t.Errorf("declination = %.5f°, want -7.78507° ± 0.001°", s.declination)
+	}
+	if math.Abs(s.eqTime-13.71) > 0.01 {
+		t.Errorf("equation of time = %.4f minutes, want 13.71 (13m42.6s) ± 0.01", s.eqTime)
+	}
+}
+
+func TestSolarPositionOverhead(t *testing.T) {
+	// At solar noon where the latitude is the sun's declination, the sun is
+	// straight overhead
+	c := &CityInfo{Name: "Subsolar", Longitude: 30}
+	noon := c.SolarNoon(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	c.Latitude = c.sun(noon).declination
+	p := c.SolarPosition(noon)
+	if math.IsNaN(p.Azimuth) || p.Azimuth < 0 || p.Azimuth >= 360 {
+		t.Errorf("SolarPosition overhead = %+v, want an azimuth in [0, 360)", p)
+	}
+	if p.Elevation < 89.99 {
+		t.Errorf("SolarPosition overhead = %+v, want an elevation of 90°", p)
+	}
+
+	// Exactly overhead, however the hour angle rounds
+	for _, at := range []time.Time{noon, noon.Add(time.Nanosecond), noon.Add(-time.Nanosecond)} {
+		c.Latitude = c.sun(at).declination
+		if az := c.SolarPosition(at).Azimuth; math.IsNaN(az) {
+			t.Errorf("azimuth at %s = NaN", at)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ProfilePoint is the altitude of the skyline in one direction.
+type ProfilePoint struct {
+	Azimuth  float64 // Degrees clockwise from north
+	Altitude float64 // Degrees above the horizon
+}
+
+// A Profile is the skyline around a place, as points sorted by azimuth.
+// Between points, the skyline is a straight line.
+type Profile []ProfilePoint
+
+// ReadProfile reads a Profile with one "AZIMUTH ALTITUDE" point per line,
+// separated by spaces or a comma. Blank lines and lines starting with # are
+// skipped.
+func ReadProfile(r io.Reader) (Profile, error) {
+	var p Profile
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(text, ",", " "))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected AZIMUTH ALTITUDE, got '%s'", line, text)
+		}
+		azimuth, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid azimuth '%s'", line, fields[0])
+		}
+		altitude, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || altitude < -90 || altitude > 90 {
+			return nil, fmt.Errorf("line %d: invalid altitude '%s'", line, fields[1])
+		}
+		p = append(p, ProfilePoint{Azimuth: math.Mod(math.Mod(azimuth, 360)+360, 360), Altitude: altitude})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("no points in profile")
+	}
+	sort.Slice(p, func(i, j int) bool {
+		return p[i].Azimuth < p[j].Azimuth
+	})
+	return p, nil
+}
+
+// Altitude returns the altitude of the skyline at azimuth, in degrees. An
+// empty Profile is a flat horizon.
+func (p Profile) Altitude(azimuth float64) float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	azimuth = math.Mod(math.Mod(azimuth, 360)+360, 360)
+	// Find the points on either side, wrapping around north
+	i := sort.Search(len(p), func(i int) bool {
+		return p[i].Azimuth >= azimuth
+	})
+	before, after := p[(i+len(p)-1)%len(p)], p[i%len(p)]
+	span := math.Mod(after.Azimuth-before.Azimuth+360, 360)
+	if span == 0 {
+		return after.Altitude
+	}
+	offset := math.Mod(azimuth-before.Azimuth+360, 360)
+	return before.Altitude + (after.Altitude-before.Altitude)*offset/span
+}
+
+// An AzimuthRange is the directions from From clockwise to To, in degrees,
+// like the part of the sky seen through a window.
+type AzimuthRange struct {
+	From float64
+	To   float64
+}
+
+// ParseAzimuthRange parses a range given as "FROM-TO", e.g. "120-200".
+func ParseAzimuthRange(s string) (AzimuthRange, error) {
+	fromStr, toStr, found := strings.Cut(s, "-")
+	from, fromErr := strconv.ParseFloat(strings.TrimSpace(fromStr), 64)
+	to, toErr := strconv.ParseFloat(strings.TrimSpace(toStr), 64)
+	if !found || fromErr != nil || toErr != nil {
+		return AzimuthRange{}, fmt.Errorf("invalid azimuth range '%s': expected FROM-TO in degrees, e.g. 120-200", s)
+	}
+	return AzimuthRange{From: from, To: to}, nil
+}
+
+// Contains returns whether azimuth is in the range.
+func (r AzimuthRange) Contains(azimuth float64) bool {
+	return math.Mod(azimuth-r.From+720, 360) <= math.Mod(r.To-r.From+720, 360)
+}
+
+func (r AzimuthRange) String() string {
+	return fmt.Sprintf("%g°-%g°", r.From, r.To)
+}
+
+// Obstructed returns whether c's Horizon has a Profile or Window.
+func (c *CityInfo) Obstructed() bool {
+	return len(c.Horizon.Profile) > 0 || c.Horizon.Window != nil
+}
+
+// DirectSun returns whether direct sun reaches c at the given instant: the
+// sun's center is over c's Profile and through its Window. With a flat
+// horizon, that's between sunrise and sunset.
+func (c *CityInfo) DirectSun(at time.Time) bool {
+	pos := c.SolarPosition(at)
+	if c.Horizon.Window != nil && !c.Horizon.Window.Contains(pos.Azimuth) {
+		return false
+	}
+	if len(c.Horizon.Profile) == 0 {
+		return c.geometricElevation(at) > c.EventAltitude(Sunrise, at)
+	}
+	return pos.Elevation > c.Horizon.Profile.Altitude(pos.Azimuth)
+}
+
+// DirectSunWindows returns the spans between from and to when c gets direct
+// sun, to the second.
+func (c *CityInfo) DirectSunWindows(from, to time.Time) []Window {
+	const step = 5 * time.Minute
+	var windows []Window
+	var start time.Time
+	lit := c.DirectSun(from)
+	if lit {
+		start = from
+	}
+	for t := from; t.Before(to); t = t.Add(step) {
+		lo, hi := t, t.Add(step)
+		if hi.After(to) {
+			hi = to
+		}
+		if c.DirectSun(hi) == lit {
+			continue
+		}
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if c.DirectSun(mid) == lit {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		hi = hi.Round(time.Second)
+		if lit {
+			windows = append(windows, Window{Start: start, End: hi})
+		} else {
+			start = hi
+		}
+		lit = !lit
+	}
+	if lit {
+		windows = append(windows, Window{Start: start, End: to})
+	}
+	return windows
+}
+
+// EffectiveSunriseSunset returns when direct sun first reaches c on the
+// calendar day of day, and when it last leaves, in day's location.
+func (c *CityInfo) EffectiveSunriseSunset(day time.Time) (sunrise, sunset time.Time, err error) {
+	y, m, d := day.Date()
+	windows := c.DirectSunWindows(
+		time.Date(y, m, d, 0, 0, 0, 0, day.Location()),
+		time.Date(y, m, d+1, 0, 0, 0, 0, day.Location()),
+	)
+	if len(windows) == 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("no direct sun reaches %s on %s", c.Name, day.Format("2006-01-02"))
+	}
+	return windows[0].Start, windows[len(windows)-1].End, nil
+}
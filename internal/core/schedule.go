@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule is a recurring time relative to the sun, like a cron entry for
+// solar events. Expressions are an anchor, an optional offset, and an
+// optional set of weekdays:
+//
+//	sunset-30m
+//	civil_dawn+10m mon-fri
+//	75%day
+//	noon sat,sun
+//
+// Anchors are any of the named events given to ParseSchedule, "noon" for
+// solar noon, or "N%day" and "N%night" for the moment N percent of the day or
+// night has passed, as GetPeriodPercent measures it.
+type Schedule struct {
+	expr     string
+	anchor   func(c *CityInfo, day time.Time) (time.Time, bool)
+	offset   time.Duration
+	weekdays [7]bool
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// If a schedule doesn't occur for this many days in a row, give up looking.
+// This is long enough for the sun to come back after a polar night.
+const scheduleSearchDays = 366
+
+// ParseSchedule parses a schedule whose anchors can be any of events, usually
+// a city's AllEvents. Later events win, as with FindEvent.
+func ParseSchedule(expr string, events []Event) (*Schedule, error) {
+	fields := strings.Fields(strings.ToLower(expr))
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid schedule '%s': expected an anchor like 'sunset-30m', optionally followed by weekdays like 'mon-fri'", expr)
+	}
+
+	s := &Schedule{expr: expr}
+	anchor := fields[0]
+	if i := strings.IndexAny(anchor, "+-"); i >= 0 {
+		offset, err := time.ParseDuration(anchor[i:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset in schedule '%s': %w", expr, err)
+		}
+		s.offset = offset
+		anchor = anchor[:i]
+	}
+
+	var err error
+	s.anchor, err = parseAnchor(anchor, events)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule '%s': %w", expr, err)
+	}
+
+	if len(fields) == 1 {
+		for i := range s.weekdays {
+			s.weekdays[i] = true
+		}
+		return s, nil
+	}
+	if err := parseWeekdays(fields[1], &s.weekdays); err != nil {
+		return nil, fmt.Errorf("invalid schedule '%s': %w", expr, err)
+	}
+	return s, nil
+}
+
+func parseAnchor(anchor string, events []Event) (func(c *CityInfo, day time.Time) (time.Time, bool), error) {
+	if anchor == "noon" {
+		return func(c *CityInfo, day time.Time) (time.Time, bool) {
+			return c.SolarNoon(day), true
+		}, nil
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		// The expression is lowercased, but custom event names needn't be
+		if e := events[i]; strings.EqualFold(anchor, e.Name) {
+			return func(c *CityInfo, day time.Time) (time.Time, bool) {
+				return c.EventTime(e, day)
+			}, nil
+		}
+	}
+
+	percentStr, period, found := strings.Cut(anchor, "%")
+	if !found {
+		var names []string
+		for _, e := range events {
+			names = append(names, e.Name)
+		}
+		return nil, fmt.Errorf("unknown anchor '%s': expected noon, N%%day, N%%night, or one of %s", anchor, strings.Join(names, ", "))
+	}
+	percent, err := strconv.ParseFloat(percentStr, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("percent must be a number from 0 to 100, got '%s'", percentStr)
+	}
+	fraction := percent / 100
+
+	switch period {
+	case "day":
+		return func(c *CityInfo, day time.Time) (time.Time, bool) {
+			sunrise, sunset, err := c.GetSunriseSunset(day)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return sunrise.Add(time.Duration(fraction * float64(sunset.Sub(sunrise)))), true
+		}, nil
+	case "night":
+		return func(c *CityInfo, day time.Time) (time.Time, bool) {
+			sunrise, sunset, err := c.GetSunriseSunset(day)
+			if err != nil {
+				return time.Time{}, false
+			}
+			nightDuration := 24*time.Hour - sunset.Sub(sunrise)
+			return sunset.Add(time.Duration(fraction * float64(nightDuration))), true
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown period '%s': expected day or night", period)
+}
+
+// parseWeekdays parses a comma separated list of days and ranges of days,
+// like "mon-fri" or "mon,wed,fri-sun".
+func parseWeekdays(spec string, weekdays *[7]bool) error {
+	for _, part := range strings.Split(spec, ",") {
+		startName, endName, isRange := strings.Cut(part, "-")
+		if !isRange {
+			endName = startName
+		}
+		start, ok := weekdayNames[startName]
+		if !ok {
+			return fmt.Errorf("unknown weekday '%s'", startName)
+		}
+		end, ok := weekdayNames[endName]
+		if !ok {
+			return fmt.Errorf("unknown weekday '%s'", endName)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			weekdays[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// At returns when s happens on the calendar day of day, in day's location.
+// ok is false if the anchor doesn't happen that day, or if it happens on a
+// weekday s excludes.
+func (s *Schedule) At(c *CityInfo, day time.Time) (t time.Time, ok bool) {
+	t, ok = s.anchor(c, day)
+	if !ok {
+		return time.Time{}, false
+	}
+	t = t.Add(s.offset)
+	if !s.weekdays[t.Weekday()] {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Next returns the next n times s happens after the given time, in its
+// location. It returns fewer than n times if s stops happening, such as at
+// high latitudes, and an error if s doesn't happen at all within a year,
+// or n is less than 1.
+func (s *Schedule) Next(c *CityInfo, after time.Time, n int) ([]time.Time, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("can't find %d times of '%s'", n, s.expr)
+	}
+	var times []time.Time
+	y, m, d := after.Date()
+	// Start the day before, in case an offset pushes yesterday's event past after.
+	for i, missed := -1, 0; len(times) < n && missed < scheduleSearchDays; i++ {
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, after.Location())
+		t, ok := s.At(c, day)
+		if !ok || !t.After(after) {
+			missed++
+			continue
+		}
+		missed = 0
+		times = append(times, t)
+	}
+
+	if len(times) == 0 {
+		return nil, fmt.Errorf("'%s' does not happen in %s within a year of %s", s.expr, c.Name, after.Format("2006-01-02"))
+	}
+	return times, nil
+}
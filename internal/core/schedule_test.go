@@ -0,0 +1,346 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	denver  = &CityInfo{Name: "Denver", CountryCode: "US", FipsCode: "CO", Latitude: 39.74, Longitude: -104.98, TimeZone: "America/Denver", Horizon: Horizon{Dip: true}}
+	tromso  = &CityInfo{Name: "Tromsø", CountryCode: "NO", FipsCode: "54", Latitude: 69.65, Longitude: 18.96, TimeZone: "Europe/Oslo", Horizon: Horizon{Dip: true}}
+	npole   = &CityInfo{Name: "North Pole", Latitude: 90, TimeZone: "UTC"}
+	denverZ = mustLoadLocation("America/Denver")
+	osloZ   = mustLoadLocation("Europe/Oslo")
+)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+func mustParseSchedule(t *testing.T, expr string) *Schedule {
+	t.Helper()
+	s, err := ParseSchedule(expr, Events)
+	if err != nil {
+		t.Fatalf("ParseSchedule(%q): %v", expr, err)
+	}
+	return s
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "expected an anchor"},
+		{"sunset mon-fri weekly", "expected an anchor"},
+		{"sunset+5x", "invalid offset"},
+		{"sunset-", "invalid offset"},
+		{"moonrise", "unknown anchor 'moonrise'"},
+		{"150%day", "percent must be a number from 0 to 100"},
+		{"-5%day", "invalid offset"},
+		{"half%day", "percent must be a number from 0 to 100"},
+		{"50%week", "unknown period 'week'"},
+		{"sunset funday", "unknown weekday 'funday'"},
+		{"sunset mon-funday", "unknown weekday 'funday'"},
+		{"sunset mon,,fri", "unknown weekday ''"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSchedule(tt.expr, Events)
+		if err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error containing %q", tt.expr, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSchedule(%q) = %q, want an error containing %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleWeekdays(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []time.Weekday
+	}{
+		{"noon", []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}},
+		{"noon mon-fri", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{"noon sat,sun", []time.Weekday{time.Sunday, time.Saturday}},
+		// Ranges wrap around the end of the week
+		{"noon fri-mon", []time.Weekday{time.Sunday, time.Monday, time.Friday, time.Saturday}},
+		{"noon mon,wed,fri-sun", []time.Weekday{time.Sunday, time.Monday, time.Wednesday, time.Friday, time.Saturday}},
+		{"NOON Sat", []time.Weekday{time.Saturday}},
+	}
+	for _, tt := range tests {
+		s := mustParseSchedule(t, tt.expr)
+		var want [7]bool
+		for _, d := range tt.want {
+			want[d] = true
+		}
+		if s.weekdays != want {
+			t.Errorf("ParseSchedule(%q).weekdays = %v, want %v", tt.expr, s.weekdays, want)
+		}
+	}
+}
+
+func TestScheduleAtWeekdayRange(t *testing.T) {
+	s := mustParseSchedule(t, "noon fri-mon")
+	// Monday 2024-06-17 to Sunday 2024-06-23
+	for d := 17; d <= 23; d++ {
+		day := time.Date(2024, 6, d, 12, 0, 0, 0, denverZ)
+		got, ok := s.At(denver, day)
+		wd := day.Weekday()
+		want := wd == time.Friday || wd == time.Saturday || wd == time.Sunday || wd == time.Monday
+		if ok != want {
+			t.Errorf("At(%s) ok = %v, want %v", wd, ok, want)
+		}
+		if ok && got.Weekday() != wd {
+			t.Errorf("At(%s) = %s, on the wrong day", wd, got)
+		}
+	}
+}
+
+func TestScheduleOffsetCrossesMidnight(t *testing.T) {
+	// Thursday's sunset, 20:31, plus six hours is early Friday morning, so
+	// it counts as Friday.
+	s := mustParseSchedule(t, "sunset+6h fri")
+	thursday := time.Date(2024, 6, 20, 12, 0, 0, 0, denverZ)
+	got, ok := s.At(denver, thursday)
+	if !ok {
+		t.Fatalf("At(Thursday) ok = false, want Thursday's sunset plus 6h on Friday")
+	}
+	if got.Weekday() != time.Friday || got.Day() != 21 || got.Hour() != 2 {
+		t.Errorf("At(Thursday) = %s, want about 02:31 on Friday the 21st", got)
+	}
+	if _, ok := s.At(denver, thursday.AddDate(0, 0, 1)); ok {
+		t.Errorf("At(Friday) ok = true, but Friday's sunset plus 6h is on Saturday")
+	}
+
+	times, err := s.Next(denver, thursday, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{21, 28} {
+		if times[i].Day() != want || times[i].Weekday() != time.Friday {
+			t.Errorf("Next()[%d] = %s, want Friday the %dth", i, times[i], want)
+		}
+	}
+
+	// Going back across midnight, from Monday's sunrise to Sunday night
+	s = mustParseSchedule(t, "sunrise-6h sun")
+	monday := time.Date(2024, 6, 17, 12, 0, 0, 0, denverZ)
+	got, ok = s.At(denver, monday)
+	if !ok || got.Weekday() != time.Sunday || got.Day() != 16 {
+		t.Errorf("At(Monday) = %s, %v, want Sunday the 16th", got, ok)
+	}
+}
+
+// checkDaily checks that times are in loc, and a solar day apart, give or
+// take tolerance. They needn't be on consecutive calendar days: 50%night
+// falls just after midnight, so around a change back from daylight saving
+// time, two in a row can fall just before and just after midnight.
+func checkDaily(t *testing.T, times []time.Time, loc *time.Location, tolerance time.Duration) {
+	t.Helper()
+	for i, tm := range times {
+		if tm.Location() != loc {
+			t.Errorf("times[%d] = %s, not in %s", i, tm, loc)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := times[i-1]
+		if gap := tm.Sub(prev); gap < 24*time.Hour-tolerance || gap > 24*time.Hour+tolerance {
+			t.Errorf("times[%d] = %s is %s after %s, want about 24h", i, tm, gap, prev)
+		}
+	}
+}
+
+// checkConsecutive checks that times are on consecutive calendar days.
+func checkConsecutive(t *testing.T, times []time.Time) {
+	t.Helper()
+	for i := 1; i < len(times); i++ {
+		if next := times[i-1].AddDate(0, 0, 1); times[i].YearDay() != next.YearDay() {
+			t.Errorf("times[%d] = %s is not the day after %s", i, times[i], times[i-1])
+		}
+	}
+}
+
+func TestScheduleNextSpringForward(t *testing.T) {
+	// Clocks in Denver went from 02:00 MST to 03:00 MDT on 2024-03-10
+	after := time.Date(2024, 3, 9, 0, 0, 0, 0, denverZ)
+	for _, expr := range []string{"sunrise", "noon", "sunset", "50%night", "civil_dawn-4h"} {
+		times, err := mustParseSchedule(t, expr).Next(denver, after, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if len(times) != 3 {
+			t.Fatalf("%s: got %d times, want 3", expr, len(times))
+		}
+		checkDaily(t, times, denverZ, 5*time.Minute)
+		if expr != "50%night" {
+			checkConsecutive(t, times)
+		}
+	}
+
+	// The same instant a day later reads an hour later on the clock
+	times, _ := mustParseSchedule(t, "noon").Next(denver, after, 2)
+	if times[0].Hour() != 12 || times[1].Hour() != 13 {
+		t.Errorf("solar noon on the 9th and 10th = %s, %s, want about 12:1x MST and 13:1x MDT", times[0], times[1])
+	}
+	if _, offset := times[1].Zone(); offset != -6*3600 {
+		t.Errorf("solar noon on the 10th = %s, want MDT", times[1])
+	}
+}
+
+func TestScheduleNextFallBack(t *testing.T) {
+	// Clocks in Denver went from 02:00 MDT back to 01:00 MST on 2024-11-03
+	after := time.Date(2024, 11, 2, 0, 0, 0, 0, denverZ)
+	for _, expr := range []string{"sunrise", "noon", "sunset", "50%night", "civil_dawn-5h"} {
+		times, err := mustParseSchedule(t, expr).Next(denver, after, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if len(times) != 3 {
+			t.Fatalf("%s: got %d times, want 3", expr, len(times))
+		}
+		checkDaily(t, times, denverZ, 5*time.Minute)
+		if expr != "50%night" {
+			checkConsecutive(t, times)
+		}
+	}
+
+	times, _ := mustParseSchedule(t, "noon").Next(denver, after, 2)
+	if times[0].Hour() != 12 || times[1].Hour() != 11 {
+		t.Errorf("solar noon on the 2nd and 3rd = %s, %s, want about 12:4x MDT and 11:4x MST", times[0], times[1])
+	}
+}
+
+func TestScheduleNextPolarNight(t *testing.T) {
+	// The sun doesn't rise in Tromsø from late November to mid January
+	after := time.Date(2024, 12, 1, 12, 0, 0, 0, osloZ)
+	times, err := mustParseSchedule(t, "sunrise").Next(tromso, after, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := times[0]; first.Year() != 2025 || first.Month() != time.January || first.Day() < 10 || first.Day() > 20 {
+		t.Errorf("first sunrise after %s = %s, want mid January 2025", after, first)
+	}
+	// Sunrise comes over a quarter of an hour earlier each day here
+	checkDaily(t, times, osloZ, 30*time.Minute)
+	checkConsecutive(t, times)
+
+	// Percents of the day need a sunrise and a sunset too
+	times, err = mustParseSchedule(t, "50%day").Next(tromso, after, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if times[0].Month() != time.January {
+		t.Errorf("first 50%%day after %s = %s, want in January", after, times[0])
+	}
+}
+
+func TestScheduleNextMidnightSun(t *testing.T) {
+	// The sun doesn't set in Tromsø from late May to late July
+	after := time.Date(2024, 6, 1, 12, 0, 0, 0, osloZ)
+	times, err := mustParseSchedule(t, "sunset").Next(tromso, after, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := times[0]; first.Month() != time.July || first.Day() < 15 {
+		t.Errorf("first sunset after %s = %s, want late July", after, first)
+	}
+
+	// Civil dusk doesn't happen again until after the summer either
+	times, err = mustParseSchedule(t, "civil_dusk").Next(tromso, after, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := times[0]; first.Month() < time.July {
+		t.Errorf("first civil dusk after %s = %s, want after June", after, first)
+	}
+}
+
+func TestScheduleNextGivesUp(t *testing.T) {
+	// At the pole the sun only rises once a year, too slowly to cross the
+	// horizon within any one day, so the search gives up after a year.
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"sunrise", "astronomical_dusk", "50%day", "25%night mon"} {
+		times, err := mustParseSchedule(t, expr).Next(npole, after, 1)
+		if err == nil {
+			t.Errorf("%s at the pole = %v, want an error", expr, times)
+		} else if !strings.Contains(err.Error(), "within a year") {
+			t.Errorf("%s at the pole: %q, want an error about a year", expr, err)
+		}
+	}
+
+	// Solar noon still happens every day there
+	times, err := mustParseSchedule(t, "noon").Next(npole, after, 3)
+	if err != nil || len(times) != 3 {
+		t.Errorf("noon at the pole = %v, %v, want 3 times", times, err)
+	}
+}
+
+func TestParseScheduleCustomEvents(t *testing.T) {
+	m, err := FindMethod("egypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	walk, err := ParseEvent("Photo_Walk:10:setting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	city := *denver
+	city.Events = append(append([]Event{}, m.Events...), walk)
+	day := time.Date(2024, 6, 21, 12, 0, 0, 0, denverZ)
+
+	for _, tt := range []struct {
+		expr  string
+		event Event
+	}{
+		{"fajr", m.Events[0]},
+		{"photo_walk-10m", walk},
+	} {
+		s, err := ParseSchedule(tt.expr, city.AllEvents())
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.expr, err)
+		}
+		got, ok := s.At(&city, day)
+		want, _ := city.EventTime(tt.event, day)
+		if !ok || !got.Equal(want.Add(s.offset)) {
+			t.Errorf("ParseSchedule(%q).At = %s, %v, want %s", tt.expr, got, ok, want.Add(s.offset))
+		}
+	}
+
+	// A city's own events replace the built in ones
+	city.Events = []Event{{Name: "sunset", Altitude: 10}}
+	s, err := ParseSchedule("sunset", city.AllEvents())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := s.At(&city, day)
+	want, _ := city.EventTime(city.Events[0], day)
+	if !got.Equal(want) {
+		t.Errorf("sunset at 10° = %s, want %s", got, want)
+	}
+
+	// Without the events, they're unknown
+	if _, err := ParseSchedule("fajr", Events); err == nil || !strings.Contains(err.Error(), "unknown anchor 'fajr'") {
+		t.Errorf("ParseSchedule(fajr) without the method = %v, want an unknown anchor", err)
+	}
+	if _, err := ParseEvent("photo-walk:10:setting"); err == nil {
+		t.Errorf("ParseEvent(photo-walk:10:setting) succeeded, but the name can't be an anchor")
+	}
+}
+
+func TestScheduleNextNeedsACount(t *testing.T) {
+	s := mustParseSchedule(t, "sunset")
+	after := time.Date(2024, 6, 1, 0, 0, 0, 0, denverZ)
+	for _, n := range []int{0, -1} {
+		if _, err := s.Next(denver, after, n); err == nil || strings.Contains(err.Error(), "within a year") {
+			t.Errorf("Next(n=%d) = %v, want an error about n", n, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+// A SeasonEvent is an equinox or solstice: the moment the sun reaches a
+// longitude along the ecliptic.
+type SeasonEvent struct {
+	Name      string
+	Longitude float64 // Degrees
+}
+
+var (
+	MarchEquinox     = SeasonEvent{Name: "March equinox", Longitude: 0}
+	JuneSolstice     = SeasonEvent{Name: "June solstice", Longitude: 90}
+	SeptemberEquinox = SeasonEvent{Name: "September equinox", Longitude: 180}
+	DecemberSolstice = SeasonEvent{Name: "December solstice", Longitude: 270}
+
+	Solstices = []SeasonEvent{JuneSolstice, DecemberSolstice}
+	Equinoxes = []SeasonEvent{MarchEquinox, SeptemberEquinox}
+)
+
+func (s SeasonEvent) String() string {
+	return s.Name
+}
+
+// Mean length of the tropical year, in days.
+const tropicalYear = 365.24219
+
+// seasonEventNear returns when the sun reaches s's longitude, closest to
+// guess.
+func seasonEventNear(s SeasonEvent, guess time.Time) time.Time {
+	t := guess
+	for i := 0; i < 5; i++ {
+		offset := math.Mod(sunAt(t).longitude-s.Longitude+540, 360) - 180
+		t = t.Add(-time.Duration(offset / 360 * tropicalYear * float64(24*time.Hour)))
+	}
+	return t.Round(time.Second)
+}
+
+// NextSeasonEvent returns the first of events to happen after at.
+func NextSeasonEvent(at time.Time, events ...SeasonEvent) (next SeasonEvent, t time.Time) {
+	longitude := sunAt(at).longitude
+	for _, e := range events {
+		ahead := math.Mod(e.Longitude-longitude+360, 360)
+		et := seasonEventNear(e, at.Add(time.Duration(ahead/360*tropicalYear*float64(24*time.Hour))))
+		if !et.After(at) {
+			et = seasonEventNear(e, et.Add(time.Duration(tropicalYear*float64(24*time.Hour))))
+		}
+		if t.IsZero() || et.Before(t) {
+			next, t = e, et
+		}
+	}
+	return next, t.In(at.Location())
+}
+
+// PreviousSeasonEvent returns the last of events to happen at or before at.
+func PreviousSeasonEvent(at time.Time, events ...SeasonEvent) (previous SeasonEvent, t time.Time) {
+	longitude := sunAt(at).longitude
+	for _, e := range events {
+		behind := math.Mod(longitude-e.Longitude+360, 360)
+		et := seasonEventNear(e, at.Add(-time.Duration(behind/360*tropicalYear*float64(24*time.Hour))))
+		if et.After(at) {
+			et = seasonEventNear(e, et.Add(-time.Duration(tropicalYear*float64(24*time.Hour))))
+		}
+		if t.IsZero() || et.After(t) {
+			previous, t = e, et
+		}
+	}
+	return previous, t.In(at.Location())
+}
+
+// DayLength is how long the day is, and how that's changing.
+type DayLength struct {
+	Length               time.Duration
+	ChangeSinceYesterday time.Duration
+
+	LastSolstice        SeasonEvent
+	LastSolsticeTime    time.Time
+	ChangeSinceSolstice time.Duration
+
+	NextSolstice     SeasonEvent
+	NextSolsticeTime time.Time
+	NextEquinox      SeasonEvent
+	NextEquinoxTime  time.Time
+}
+
+// DayLength returns the length of the day on the calendar day of day, and
+// how it has changed since yesterday and since the last solstice. Days of
+// midnight sun are 24 hours long, and days of polar night are 0.
+func (c *CityInfo) DayLength(day time.Time) *DayLength {
+	length := func(day time.Time) time.Duration {
+		sunrise, sunset, err := c.GetSunriseSunset(day)
+		if err == nil {
+			return sunset.Sub(sunrise)
+		}
+		if c.geometricElevation(c.SolarNoon(day)) > c.EventAltitude(Sunrise, day) {
+			return 24 * time.Hour
+		}
+		return 0
+	}
+
+	y, m, d := day.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, day.Location())
+	today := length(noon)
+	dl := &DayLength{Length: today, ChangeSinceYesterday: today - length(noon.AddDate(0, 0, -1))}
+
+	dl.LastSolstice, dl.LastSolsticeTime = PreviousSeasonEvent(noon, Solstices...)
+	dl.ChangeSinceSolstice = today - length(dl.LastSolsticeTime)
+	dl.NextSolstice, dl.NextSolsticeTime = NextSeasonEvent(noon, Solstices...)
+	dl.NextEquinox, dl.NextEquinoxTime = NextSeasonEvent(noon, Equinoxes...)
+	return dl
+}
+
+// DayLength returns the length of p.At's day and how it's changing.
+func (p *Period) DayLength() *DayLength {
+	return p.City.DayLength(p.At)
+}
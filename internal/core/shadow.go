@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// CompassPoint returns the nearest of the 16 compass points to bearing, in
+// degrees clockwise from north.
+func CompassPoint(bearing float64) string {
+	return compassPoints[int(math.Floor(math.Mod(bearing+360, 360)/22.5+0.5))%16]
+}
+
+// A Shadow is the shadow a vertical object casts on level ground.
+type Shadow struct {
+	At        time.Time
+	Height    float64 // Of the object, in any unit
+	Length    float64 // In the same unit as Height
+	Unit      string  // Of Height and Length, for printing, e.g. "m". May be empty.
+	Bearing   float64 // Degrees clockwise from north that the shadow points
+	Elevation float64 // Of the sun, in degrees
+}
+
+// Shadow returns the shadow an object height tall casts at the given
+// instant. ok is false if the sun is down.
+func (c *CityInfo) Shadow(at time.Time, height float64) (s Shadow, ok bool) {
+	pos := c.SolarPosition(at)
+	if pos.Elevation <= 0 {
+		return Shadow{At: at, Height: height, Elevation: pos.Elevation}, false
+	}
+	return Shadow{
+		At:        at,
+		Height:    height,
+		Length:    height / math.Tan(deg2rad(pos.Elevation)),
+		Bearing:   math.Mod(pos.Azimuth+180, 360),
+		Elevation: pos.Elevation,
+	}, true
+}
+
+// Direction returns the compass point the shadow points toward.
+func (s Shadow) Direction() string {
+	return CompassPoint(s.Bearing)
+}
+
+// Ratio returns how many times longer than the object the shadow is.
+func (s Shadow) Ratio() float64 {
+	return s.Length / s.Height
+}
+
+func (s Shadow) String() string {
+	return fmt.Sprintf("%.2f%s toward %.0f° (%s)", s.Length, s.Unit, s.Bearing, s.Direction())
+}
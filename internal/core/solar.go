@@ -0,0 +1,294 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+// Solar calculations follow the NOAA solar calculator:
+// https://gml.noaa.gov/grad/solcalc/calcdetails.html
+// This is the same spreadsheet github.com/kelvins/sunrisesunset was built from,
+// evaluated once per instant instead of once per second of the day, and with
+// the zenith of the event left up to the caller.
+//
+// Times are converted to Julian days without going through nanoseconds, so
+// any year time.Time can hold works, and the sun's position is computed in
+// Terrestrial Time using an estimate of ΔT. The series are fitted to the
+// present, though: events are good to a minute or so from 1800 to 2100, a
+// few minutes back to -1000 and up to 3000, and worse beyond, where the
+// uncertainty in ΔT alone grows to hours. Dates are proleptic Gregorian, so
+// a Julian calendar date like 1066-10-14 is 1066-10-20 here.
+
+// An Event is the moment the center of the sun crosses an altitude,
+// either rising in the morning or setting in the evening.
+type Event struct {
+	Name     string
+	Altitude float64 // Degrees above the horizon
+	Rising   bool
+	// Whether the event is the sun crossing the observer's horizon, in which
+	// case Altitude is lowered by the city's Horizon corrections.
+	OnHorizon bool
+	// If nonzero, the event is instead when the shadow of a vertical stick is
+	// ShadowFactor times the stick's length longer than it was at solar noon,
+	// as for Asr, and Altitude is ignored.
+	ShadowFactor float64
+}
+
+var (
+	// Standard refraction (34') plus the sun's semidiameter (16').
+	Sunrise = Event{Name: "sunrise", Altitude: -0.833, Rising: true, OnHorizon: true}
+	Sunset  = Event{Name: "sunset", Altitude: -0.833, Rising: false, OnHorizon: true}
+
+	CivilDawn        = Event{Name: "civil_dawn", Altitude: -6, Rising: true}
+	CivilDusk        = Event{Name: "civil_dusk", Altitude: -6, Rising: false}
+	NauticalDawn     = Event{Name: "nautical_dawn", Altitude: -12, Rising: true}
+	NauticalDusk     = Event{Name: "nautical_dusk", Altitude: -12, Rising: false}
+	AstronomicalDawn = Event{Name: "astronomical_dawn", Altitude: -18, Rising: true}
+	AstronomicalDusk = Event{Name: "astronomical_dusk", Altitude: -18, Rising: false}
+
+	// Golden hour is while the sun is between -4° and 6°, and blue hour while
+	// it is between -6° and -4°. So the morning blue hour starts at civil dawn
+	// and ends when the morning golden hour starts, and the evening blue hour
+	// starts when the evening golden hour ends and ends at civil dusk.
+	MorningGoldenHourStart = Event{Name: "morning_golden_hour_start", Altitude: GoldenHourLow, Rising: true}
+	MorningGoldenHourEnd   = Event{Name: "morning_golden_hour_end", Altitude: GoldenHourHigh, Rising: true}
+	EveningGoldenHourStart = Event{Name: "evening_golden_hour_start", Altitude: GoldenHourHigh, Rising: false}
+	EveningGoldenHourEnd   = Event{Name: "evening_golden_hour_end", Altitude: GoldenHourLow, Rising: false}
+)
+
+// Altitudes bounding golden hour and blue hour, in degrees.
+const (
+	GoldenHourHigh = 6
+	GoldenHourLow  = -4
+	BlueHourLow    = -6
+)
+
+// Events is every named event, in the order they happen through a day.
+// Methods have more.
+var Events = []Event{
+	AstronomicalDawn,
+	NauticalDawn,
+	CivilDawn,
+	MorningGoldenHourStart,
+	Sunrise,
+	MorningGoldenHourEnd,
+	EveningGoldenHourStart,
+	Sunset,
+	EveningGoldenHourEnd,
+	CivilDusk,
+	NauticalDusk,
+	AstronomicalDusk,
+}
+
+func (e Event) String() string {
+	return e.Name
+}
+
+func deg2rad(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func rad2deg(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// julianDay returns the Julian day of t in Universal Time.
+func julianDay(t time.Time) float64 {
+	// t.UnixNano overflows outside 1678 to 2262
+	return float64(t.Unix())/86400 + float64(t.Nanosecond())/float64(24*time.Hour) + 2440587.5
+}
+
+// julianCentury returns the Julian centuries since J2000.0 of t in
+// Terrestrial Time, which the sun and moon's motion is measured in.
+func julianCentury(t time.Time) float64 {
+	return (julianDay(t) + deltaT(t)/86400 - 2451545) / 36525
+}
+
+// deltaT estimates TT - UT at t in seconds, using the polynomials of Espenak
+// and Meeus from the NASA Five Millennium Canon of Solar Eclipses.
+func deltaT(t time.Time) float64 {
+	y := float64(t.Year()) + (float64(t.YearDay())-0.5)/365.25
+	switch {
+	case y < -500 || y >= 2150:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	case y < 500:
+		u := y / 100
+		return 10583.6 + u*(-1014.41+u*(33.78311+u*(-5.952053+u*(-0.1798452+u*(0.022174192+u*0.0090316521)))))
+	case y < 1600:
+		u := (y - 1000) / 100
+		return 1574.2 + u*(-556.01+u*(71.23472+u*(0.319781+u*(-0.8503463+u*(-0.005050998+u*0.0083572073)))))
+	case y < 1700:
+		u := y - 1600
+		return 120 + u*(-0.9808+u*(-0.01532+u/7129))
+	case y < 1800:
+		u := y - 1700
+		return 8.83 + u*(0.1603+u*(-0.0059285+u*(0.00013336-u/1174000)))
+	case y < 1860:
+		u := y - 1800
+		return 13.72 + u*(-0.332447+u*(0.0068612+u*(0.0041116+u*(-0.00037436+u*(0.0000121272+u*(-0.0000001699+u*0.000000000875))))))
+	case y < 1900:
+		u := y - 1860
+		return 7.62 + u*(0.5737+u*(-0.251754+u*(0.01680668+u*(-0.0004473624+u/233174))))
+	case y < 1920:
+		u := y - 1900
+		return -2.79 + u*(1.494119+u*(-0.0598939+u*(0.0061966-u*0.000197)))
+	case y < 1941:
+		u := y - 1920
+		return 21.20 + u*(0.84493+u*(-0.076100+u*0.0020936))
+	case y < 1961:
+		u := y - 1950
+		return 29.07 + u*(0.407+u*(-1/233.0+u/2547))
+	case y < 1986:
+		u := y - 1975
+		return 45.45 + u*(1.067+u*(-1/260.0-u/718))
+	case y < 2005:
+		u := y - 2000
+		return 63.86 + u*(0.3345+u*(-0.060374+u*(0.0017275+u*(0.000651814+u*0.00002373599))))
+	case y < 2050:
+		u := y - 2000
+		return 62.92 + u*(0.32217+u*0.005589)
+	default:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-y)
+	}
+}
+
+// sunCoordinates describes where the sun is at an instant.
+type sunCoordinates struct {
+	declination float64 // Degrees
+	eqTime      float64 // Minutes
+	longitude   float64 // Apparent ecliptic longitude, degrees
+}
+
+func sunAt(at time.Time) sunCoordinates {
+	jc := julianCentury(at)
+
+	geomMeanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	geomMeanAnom := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccent := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+
+	eqCenter := math.Sin(deg2rad(geomMeanAnom))*(1.914602-jc*(0.004817+0.000014*jc)) +
+		math.Sin(deg2rad(2*geomMeanAnom))*(0.019993-0.000101*jc) +
+		math.Sin(deg2rad(3*geomMeanAnom))*0.000289
+	trueLong := geomMeanLong + eqCenter
+	omega := 125.04 - 1934.136*jc
+	appLong := trueLong - 0.00569 - 0.00478*math.Sin(deg2rad(omega))
+
+	meanObliq := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliq := meanObliq + 0.00256*math.Cos(deg2rad(omega))
+
+	declination := rad2deg(math.Asin(math.Sin(deg2rad(obliq)) * math.Sin(deg2rad(appLong))))
+
+	y := math.Pow(math.Tan(deg2rad(obliq/2)), 2)
+	l0 := deg2rad(geomMeanLong)
+	m := deg2rad(geomMeanAnom)
+	eqTime := 4 * rad2deg(y*math.Sin(2*l0)-
+		2*eccent*math.Sin(m)+
+		4*eccent*y*math.Sin(m)*math.Cos(2*l0)-
+		0.5*y*y*math.Sin(4*l0)-
+		1.25*eccent*eccent*math.Sin(2*m))
+
+	return sunCoordinates{
+		declination: declination,
+		eqTime:      eqTime,
+		longitude:   math.Mod(appLong+360, 360),
+	}
+}
+
+// sun returns where the sun is at an instant, with c's Precision.
+func (c *CityInfo) sun(at time.Time) sunCoordinates {
+	if c.Precision == HighPrecision {
+		return c.spaAt(at)
+	}
+	return sunAt(at)
+}
+
+// hourAngle returns the sun's hour angle in degrees at the given instant,
+// normalized to [-180, 180). It is negative before solar noon.
+func (c *CityInfo) hourAngle(at time.Time) float64 {
+	u := at.UTC()
+	midnight := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+	minutes := u.Sub(midnight).Minutes()
+
+	trueSolarTime := minutes + c.sun(at).eqTime + 4*c.Longitude
+	return math.Mod(math.Mod(trueSolarTime/4, 360)+360, 360) - 180
+}
+
+// degreesToDuration converts an angle the earth turns through to the time it
+// takes to turn through it.
+func degreesToDuration(degrees float64) time.Duration {
+	return time.Duration(degrees / 360 * float64(24*time.Hour))
+}
+
+// SolarNoon returns the moment the sun crosses the meridian nearest to noon
+// on the calendar day of day, in day's location.
+func (c *CityInfo) SolarNoon(day time.Time) time.Time {
+	y, m, d := day.Date()
+	t := time.Date(y, m, d, 12, 0, 0, 0, day.Location())
+	for i := 0; i < 3; i++ {
+		t = t.Add(-degreesToDuration(c.hourAngle(t)))
+	}
+	return t
+}
+
+// EventAltitude returns the geometric altitude of the sun's center at e on
+// the calendar day of day, in degrees, after applying c's Horizon
+// corrections. Only shadow events depend on the day.
+func (c *CityInfo) EventAltitude(e Event, day time.Time) float64 {
+	if e.ShadowFactor != 0 {
+		// The noon shadow is tan(zenith) stick lengths long. With the sun
+		// below the horizon at noon, there's no shadow to lengthen.
+		zenith := math.Min(math.Abs(c.Latitude-c.sun(c.SolarNoon(day)).declination), 90)
+		return rad2deg(math.Atan(1 / (e.ShadowFactor + math.Tan(deg2rad(zenith)))))
+	}
+	if !e.OnHorizon {
+		return e.Altitude
+	}
+	return c.Horizon.correct(e.Altitude, c.Elevation)
+}
+
+// EventTime returns when e happens on the calendar day of day, in day's
+// location. ok is false if the sun does not cross e's altitude that day, as
+// happens near the poles.
+func (c *CityInfo) EventTime(e Event, day time.Time) (t time.Time, ok bool) {
+	altitude := c.EventAltitude(e, day)
+	noon := c.SolarNoon(day)
+	t = noon
+	// The declination changes through the day, so recompute it at each guess.
+	for i := 0; i < 3; i++ {
+		declination := c.sun(t).declination
+		cosH := (math.Sin(deg2rad(altitude)) - math.Sin(deg2rad(c.Latitude))*math.Sin(deg2rad(declination))) /
+			(math.Cos(deg2rad(c.Latitude)) * math.Cos(deg2rad(declination)))
+		if cosH < -1 || cosH > 1 {
+			return time.Time{}, false
+		}
+		offset := degreesToDuration(rad2deg(math.Acos(cosH)))
+		if e.Rising {
+			offset = -offset
+		}
+		t = noon.Add(offset)
+	}
+	return t.Round(time.Second), true
+}
+
+// NextEvent returns the first of events to happen after at. ok is false if
+// none of them happen within a year, as when the sun never sets near a pole.
+func (c *CityInfo) NextEvent(at time.Time, events ...Event) (next Event, t time.Time, ok bool) {
+	y, m, d := at.Date()
+	// Start the day before, since in a far off time zone yesterday's sunset
+	// can land on today's date.
+	for i := -1; i < scheduleSearchDays; i++ {
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, at.Location())
+		for _, e := range events {
+			et, eventOk := c.EventTime(e, day)
+			if eventOk && et.After(at) && (!ok || et.Before(t)) {
+				next, t, ok = e, et, true
+			}
+		}
+		if ok {
+			return next, t, true
+		}
+	}
+	return Event{}, time.Time{}, false
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestJulianDay(t *testing.T) {
+	// From Meeus, Astronomical Algorithms, chapter 7, with the Gregorian
+	// correction for every date, as ParseTime reads them
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{time.Date(-2000, 1, 1, 0, 0, 0, 0, time.UTC), 990574.5},
+		{time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), 1721425.5},
+		// The Julian calendar's 1066-10-14, the Battle of Hastings
+		{time.Date(1066, 10, 20, 0, 0, 0, 0, time.UTC), 2110700.5},
+		{time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), 2451545},
+		{time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), 2816787.5},
+	}
+	for _, tt := range tests {
+		if got := julianDay(tt.at); got != tt.want {
+			t.Errorf("julianDay(%s) = %f, want %f", tt.at, got, tt.want)
+		}
+	}
+
+	// Negative years parse in astronomical numbering
+	at, err := ParseTime("-2000-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := julianDay(at); got != 990574.5 {
+		t.Errorf("julianDay(ParseTime(-2000-01-01)) = %f, want 990574.5", got)
+	}
+}
+
+func TestDeltaT(t *testing.T) {
+	// From the polynomials of the NASA Five Millennium Canon, as published.
+	// Before -500 and after 2150, ΔT is a parabola extrapolated from the
+	// historical record, so these are only estimates of the true value.
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{time.Date(-2000, 1, 1, 0, 0, 0, 0, time.UTC), 46676},
+		{time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), 10573},
+		{time.Date(1066, 10, 20, 0, 0, 0, 0, time.UTC), 1234},
+		{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 63.9},
+		{time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), 4436},
+	}
+	for _, tt := range tests {
+		if got := deltaT(tt.at); math.Abs(got-tt.want) > 1 {
+			t.Errorf("deltaT(%s) = %.1f s, want %.1f s", tt.at, got, tt.want)
+		}
+	}
+
+	// The polynomials meet, give or take a few seconds, where they change
+	for _, year := range []int{-500, 500, 1600, 1700, 1800, 1860, 1900, 1920, 1941, 1961, 1986, 2005, 2050, 2150} {
+		before := deltaT(time.Date(year-1, 12, 31, 0, 0, 0, 0, time.UTC))
+		after := deltaT(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC))
+		if math.Abs(after-before) > 20 {
+			t.Errorf("deltaT jumps from %.1f s to %.1f s at %d", before, after, year)
+		}
+	}
+}
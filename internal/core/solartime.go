@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// A SolarTime is what clocks set by the sun would read at an instant.
+type SolarTime struct {
+	// The clock time in the city's time zone
+	Civil time.Time
+	// Local mean solar time: noon is when the sun would cross the meridian if
+	// the earth's orbit were circular and untilted.
+	Mean time.Time
+	// Local apparent solar time: what a sundial reads. Noon is when the sun
+	// actually crosses the meridian.
+	Apparent time.Time
+	// How far apparent time is ahead of mean time
+	EquationOfTime time.Duration
+}
+
+// SolarTime returns the solar times at the given instant. The Mean and
+// Apparent times are in fixed zones named LMT and LAT, so they format like
+// any other time.
+func (c *CityInfo) SolarTime(at time.Time) SolarTime {
+	eqTime := time.Duration(c.sun(at).eqTime * float64(time.Minute)).Round(time.Second)
+	meanOffset := degreesToDuration(c.Longitude).Round(time.Second)
+	return SolarTime{
+		Civil:          at,
+		Mean:           at.In(time.FixedZone("LMT", int(meanOffset.Seconds()))),
+		Apparent:       at.In(time.FixedZone("LAT", int((meanOffset + eqTime).Seconds()))),
+		EquationOfTime: eqTime,
+	}
+}
+
+// ClockOffset returns how far a sundial is ahead of the civil clock.
+func (s SolarTime) ClockOffset() time.Duration {
+	_, civilOffset := s.Civil.Zone()
+	_, apparentOffset := s.Apparent.Zone()
+	return time.Duration(apparentOffset-civilOffset) * time.Second
+}
+
+func (s SolarTime) String() string {
+	return fmt.Sprintf(`Civil time:          %s
+Local mean time:     %s
+Local apparent time: %s
+Equation of time:    %s
+Sundial vs. clock:   %s`,
+		s.Civil.Format("15:04:05 MST"),
+		s.Mean.Format("15:04:05"),
+		s.Apparent.Format("15:04:05"),
+		FormatOffset(s.EquationOfTime),
+		FormatOffset(s.ClockOffset()))
+}
+
+// FormatOffset formats d with a sign, e.g. "+15m12s".
+func FormatOffset(d time.Duration) string {
+	if d < 0 {
+		return d.String()
+	}
+	return "+" + d.String()
+}
+
+// SolarTime returns the solar times at p.At.
+func (p *Period) SolarTime() SolarTime {
+	return p.City.SolarTime(p.At)
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// The example in appendix A.5 of the SPA paper
+var golden = &CityInfo{
+	Name:      "Golden",
+	Latitude:  39.742476,
+	Longitude: -105.1786,
+	Elevation: 1830.14,
+	Horizon:   Horizon{Refraction: ComputedRefraction, Pressure: 820, Temperature: 11},
+	Precision: HighPrecision,
+}
+
+var goldenAt = time.Date(2003, 10, 17, 12, 30, 30, 0, time.FixedZone("MST", -7*3600))
+
+func TestSPAPaperExample(t *testing.T) {
+	s := golden.spa(julianDay(goldenAt), 67)
+	tests := []struct {
+		name      string
+		got, want float64
+		tolerance float64
+	}{
+		{"L", s.l, 24.0182616917, 1e-6},
+		{"B", s.b, -0.0001011219, 1e-8},
+		{"R", s.r, 0.9965422974, 1e-9},
+		{"Δψ", s.deltaPsi, -0.00399840, 1e-7},
+		{"Δε", s.deltaEpsilon, 0.00166657, 1e-7},
+		{"α'", s.topoAlpha, 202.22704, 1e-5},
+		{"δ'", s.topoDelta, -9.316179, 1e-6},
+		// The paper's H' is 2e-5° more than its own H - Δα
+		{"H'", s.topoH, 11.10629, 3e-5},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > tt.tolerance {
+			t.Errorf("%s = %.10f, want %.10f", tt.name, tt.got, tt.want)
+		}
+	}
+
+	elevation, azimuth := golden.horizontal(s.topoDelta, s.topoH)
+	if zenith := 90 - elevation - golden.Horizon.refract(elevation); math.Abs(zenith-50.11162) > 1e-5 {
+		t.Errorf("zenith = %.5f°, want 50.11162°", zenith)
+	}
+	if math.Abs(azimuth-194.34024) > 1e-5 {
+		t.Errorf("azimuth = %.5f°, want 194.34024°", azimuth)
+	}
+}
+
+func TestSPASolarPosition(t *testing.T) {
+	// deltaT estimates ΔT as 64.5 s rather than the paper's 67 s, which moves
+	// the sun a ten-thousandth of a degree at most
+	p := golden.SolarPosition(goldenAt)
+	if zenith := 90 - p.Elevation; math.Abs(zenith-50.11162) > 1e-4 {
+		t.Errorf("zenith = %.5f°, want 50.11162°", zenith)
+	}
+	if math.Abs(p.Azimuth-194.34024) > 1e-4 {
+		t.Errorf("azimuth = %.5f°, want 194.34024°", p.Azimuth)
+	}
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormats describes the inputs ParseTime accepts.
+const TimeFormats = `RFC 3339 (2006-01-02T15:04:05-07:00), '2006-01-02 15:04', bare dates (2006-01-02),
+negative years for BCE (-0500-03-21, astronomical numbering),
+Unix timestamps (@1136239445), relative times (+3h, -30m, +2d),
+phrases (07:00, 7:30pm, tomorrow 07:00, next friday noon), or time.UnixDate (Mon Jan  2 15:04:05 MST 2006)`
+
+// GregorianStart is when the Gregorian calendar replaced the Julian one.
+// ParseTime reads earlier dates in the proleptic Gregorian calendar, not the
+// Julian calendar that sources from the time use.
+var GregorianStart = time.Date(1582, 10, 15, 0, 0, 0, 0, time.UTC)
+
+var absoluteLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.UnixDate,
+}
+
+var clockLayouts = []string{
+	"15:04",
+	"15:04:05",
+	"3pm",
+	"3:04pm",
+}
+
+// ParseTime parses a time given in any of TimeFormats. Times without an
+// explicit offset, and times relative to now, are interpreted in now's
+// location.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	loc := now.Location()
+
+	for _, layout := range absoluteLayouts {
+		// time.UnixDate has capitalized names, so use the original
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), loc); err == nil {
+			// A zone abbreviation loc doesn't use gets a made up offset of 0,
+			// which would silently be hours off
+			if name, offset := t.Zone(); t.Location() != loc && offset == 0 && name != "UTC" && name != "GMT" {
+				return time.Time{}, fmt.Errorf("unknown time zone '%s' in '%s': give an offset instead, as in RFC 3339 (2006-01-02T15:04:05-07:00)", name, value)
+			}
+			return t, nil
+		}
+	}
+
+	// Astronomical year numbering, where -0500 is 501 BCE. The Gregorian leap
+	// year rule doesn't care about sign, so parse the year as positive.
+	if strings.HasPrefix(s, "-") {
+		for _, layout := range absoluteLayouts[:len(absoluteLayouts)-1] {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(value)[1:], loc); err == nil {
+				return t.AddDate(-2*t.Year(), 0, 0), nil
+			}
+		}
+	}
+
+	if strings.HasPrefix(s, "@") {
+		seconds, err := strconv.ParseInt(s[1:], 10, 64)
+		if err == nil {
+			return time.Unix(seconds, 0).In(loc), nil
+		}
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		if strings.HasSuffix(s, "d") {
+			if n, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+				return now.AddDate(0, 0, n), nil
+			}
+		}
+		if d, err := time.ParseDuration(s); err == nil {
+			return now.Add(d), nil
+		}
+	}
+
+	if t, ok := parsePhrase(s, now); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse time '%s'. Accepted formats are %s", value, TimeFormats)
+}
+
+// parsePhrase parses a day, a time of day, or both, like "tomorrow 07:00",
+// "next friday noon", or "7:30pm". A day without a time of day keeps now's
+// time of day, and a time of day without a day is today.
+func parsePhrase(s string, now time.Time) (time.Time, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+
+	y, m, d := now.Date()
+	hour, min, sec := now.Clock()
+
+	dayFields := 0
+	switch fields[0] {
+	case "today", "now":
+		dayFields = 1
+	case "tomorrow":
+		d++
+		dayFields = 1
+	case "yesterday":
+		d--
+		dayFields = 1
+	case "next", "last":
+		if len(fields) < 2 {
+			return time.Time{}, false
+		}
+		weekday, ok := parseWeekday(fields[1])
+		if !ok {
+			return time.Time{}, false
+		}
+		delta := (int(weekday) - int(now.Weekday()) + 7) % 7
+		if fields[0] == "next" && delta == 0 {
+			delta = 7
+		}
+		if fields[0] == "last" {
+			delta -= 7
+		}
+		d += delta
+		hour, min, sec = 0, 0, 0
+		dayFields = 2
+	default:
+		if weekday, ok := parseWeekday(fields[0]); ok {
+			d += (int(weekday) - int(now.Weekday()) + 7) % 7
+			hour, min, sec = 0, 0, 0
+			dayFields = 1
+		}
+	}
+
+	clock := fields[dayFields:]
+	switch {
+	case len(clock) == 0 && dayFields == 0:
+		return time.Time{}, false
+	case len(clock) == 0:
+	case len(clock) > 1:
+		return time.Time{}, false
+	case clock[0] == "noon":
+		hour, min, sec = 12, 0, 0
+	case clock[0] == "midnight":
+		hour, min, sec = 0, 0, 0
+	default:
+		var err error
+		var t time.Time
+		for _, layout := range clockLayouts {
+			if t, err = time.Parse(layout, clock[0]); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return time.Time{}, false
+		}
+		hour, min, sec = t.Clock()
+	}
+
+	return time.Date(y, m, d, hour, min, sec, 0, now.Location()), true
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	weekday, ok := weekdayNames[s[:3]]
+	if !ok || !strings.HasPrefix(strings.ToLower(weekday.String()), s) {
+		return 0, false
+	}
+	return weekday, true
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, 6, 19, 10, 30, 15, 0, denverZ)
+	at := func(y int, m time.Month, d, hour, min, sec int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, denverZ)
+	}
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		// Absolute layouts
+		{"2024-03-10T07:05:09+01:00", time.Date(2024, 3, 10, 6, 5, 9, 0, time.UTC)},
+		{"2024-03-10T07:05:09Z", time.Date(2024, 3, 10, 7, 5, 9, 0, time.UTC)},
+		{"2024-03-10T07:05", at(2024, 3, 10, 7, 5, 0)},
+		{"2024-03-10 07:05:09", at(2024, 3, 10, 7, 5, 9)},
+		{"2024-03-10 07:05", at(2024, 3, 10, 7, 5, 0)},
+		{"2024-03-10", at(2024, 3, 10, 0, 0, 0)},
+		{" 2024-03-10 ", at(2024, 3, 10, 0, 0, 0)},
+		{"Wed Jan 10 07:05:09 MST 2024", at(2024, 1, 10, 7, 5, 9)},
+		// A zone loc uses keeps its offset, even when the date is in the other one
+		{"Sun Mar 10 07:05:09 MST 2024", at(2024, 3, 10, 8, 5, 9)},
+		{"Mon Oct 19 12:00:00 MDT 2026", at(2026, 10, 19, 12, 0, 0)},
+		{"Mon Oct 19 12:00:00 UTC 2026", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)},
+		{"Mon Oct 19 12:00:00 GMT 2026", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)},
+
+		// Negative years are astronomical, so -0500 is 501 BCE
+		{"-0500-03-21", at(-500, 3, 21, 0, 0, 0)},
+		{"-0500-03-21 06:00", at(-500, 3, 21, 6, 0, 0)},
+		{"-0004-02-29", at(-4, 2, 29, 0, 0, 0)},
+
+		// Unix timestamps
+		{"@0", time.Unix(0, 0)},
+		{"@1718814615", now},
+
+		// Relative times
+		{"+3h", now.Add(3 * time.Hour)},
+		{"-30m", now.Add(-30 * time.Minute)},
+		{"+1h30m", now.Add(90 * time.Minute)},
+		{"+2d", at(2024, 6, 21, 10, 30, 15)},
+		{"-1d", at(2024, 6, 18, 10, 30, 15)},
+
+		// Phrases
+		{"now", now},
+		{"today", now},
+		{"Today", now},
+		{"today noon", at(2024, 6, 19, 12, 0, 0)},
+		{"tomorrow", at(2024, 6, 20, 10, 30, 15)},
+		{"tomorrow 07:00", at(2024, 6, 20, 7, 0, 0)},
+		{"yesterday", at(2024, 6, 18, 10, 30, 15)},
+		{"yesterday midnight", at(2024, 6, 18, 0, 0, 0)},
+		{"next friday", at(2024, 6, 21, 0, 0, 0)},
+		{"next friday noon", at(2024, 6, 21, 12, 0, 0)},
+		{"next wednesday", at(2024, 6, 26, 0, 0, 0)},
+		{"next wed 7pm", at(2024, 6, 26, 19, 0, 0)},
+		{"last monday", at(2024, 6, 17, 0, 0, 0)},
+		{"last wednesday", at(2024, 6, 12, 0, 0, 0)},
+		{"friday", at(2024, 6, 21, 0, 0, 0)},
+		{"wednesday", at(2024, 6, 19, 0, 0, 0)},
+		{"tues 06:15", at(2024, 6, 25, 6, 15, 0)},
+		{"07:00", at(2024, 6, 19, 7, 0, 0)},
+		{"15:04:05", at(2024, 6, 19, 15, 4, 5)},
+		{"7pm", at(2024, 6, 19, 19, 0, 0)},
+		{"7:30PM", at(2024, 6, 19, 19, 30, 0)},
+		{"noon", at(2024, 6, 19, 12, 0, 0)},
+		{"midnight", at(2024, 6, 19, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	now := time.Date(2024, 6, 19, 10, 30, 15, 0, denverZ)
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "unable to parse time"},
+		{"soon", "unable to parse time"},
+		{"2024-13-01", "unable to parse time"},
+		{"@soon", "unable to parse time"},
+		{"+3 hours", "unable to parse time"},
+		{"+xd", "unable to parse time"},
+		{"next", "unable to parse time"},
+		{"next month", "unable to parse time"},
+		{"fr", "unable to parse time"},
+		{"fridays", "unable to parse time"},
+		{"tomorrow 07:00 pm", "unable to parse time"},
+		{"25:00", "unable to parse time"},
+		// Denver doesn't use EDT, so Go would read it as +0
+		{"Mon Oct 19 12:00:00 EDT 2026", "unknown time zone 'EDT'"},
+		{"Mon Oct 19 12:00:00 XYZ 2026", "unknown time zone 'XYZ'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTime(tt.value, now)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseTime(%q) error = %v, want it to contain %q", tt.value, err, tt.want)
+		}
+	}
+}
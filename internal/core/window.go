@@ -0,0 +1,72 @@
+package core
+
+import (
+	"time"
+)
+
+// A Window is a span of time, from Start up to End.
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (w Window) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// DaylightWindows returns the spans between from and to when c is between
+// minFraction and maxFraction of the way through its day. 0 and 1 give the
+// whole time from sunrise to sunset. Days the sun doesn't rise or set are
+// skipped.
+func DaylightWindows(c *CityInfo, from, to time.Time, minFraction, maxFraction float64) []Window {
+	var windows []Window
+	loc := c.Location()
+	y, m, d := from.In(loc).Date()
+	// Start the day before, since in a far off time zone yesterday's
+	// sunset can land on today's date.
+	for day := time.Date(y, m, d-1, 12, 0, 0, 0, loc); day.Before(to.Add(24 * time.Hour)); day = day.AddDate(0, 0, 1) {
+		sunrise, sunset, err := c.GetSunriseSunset(day)
+		if err != nil {
+			continue
+		}
+		length := sunset.Sub(sunrise)
+		w := Window{
+			Start: sunrise.Add(time.Duration(minFraction * float64(length))).Round(time.Second),
+			End:   sunrise.Add(time.Duration(maxFraction * float64(length))).Round(time.Second),
+		}
+		if w.Start.Before(from) {
+			w.Start = from
+		}
+		if w.End.After(to) {
+			w.End = to
+		}
+		if w.End.After(w.Start) {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// IntersectWindows returns the spans covered by both a and b. Both must be
+// sorted and non-overlapping, and so is the result.
+func IntersectWindows(a, b []Window) []Window {
+	var windows []Window
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].Start, a[i].End
+		if b[j].Start.After(start) {
+			start = b[j].Start
+		}
+		if b[j].End.Before(end) {
+			end = b[j].End
+		}
+		if end.After(start) {
+			windows = append(windows, Window{Start: start, End: end})
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return windows
+}
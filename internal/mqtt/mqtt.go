@@ -0,0 +1,218 @@
+// Package mqtt is a minimal MQTT 3.1.1 client. It can only publish, at QoS 0,
+// which is all sundial needs to keep retained topics up to date.
+package mqtt
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Control packet types, shifted into the high nibble of the first byte.
+const (
+	packetConnect    = 1 << 4
+	packetConnAck    = 2 << 4
+	packetPublish    = 3 << 4
+	packetPingReq    = 12 << 4
+	packetDisconnect = 14 << 4
+)
+
+var connectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Options configure a connection.
+type Options struct {
+	ClientID string
+	Username string
+	Password string
+	// How long the broker should wait to hear from the client before
+	// dropping it and publishing the will. The client pings the broker
+	// twice as often. Zero turns it off.
+	KeepAlive time.Duration
+
+	// Published by the broker, retained, if the client goes away without
+	// disconnecting. No will if WillTopic is empty.
+	WillTopic   string
+	WillPayload []byte
+}
+
+// A Client is a connection to a broker.
+type Client struct {
+	mu        sync.Mutex
+	conn      net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker at a URL like tcp://localhost:1883, or
+// ssl://host:8883 for TLS.
+func Dial(broker string, opts Options) (*Client, error) {
+	// MQTT 3.1.1 only allows a password after a user name
+	if opts.Password != "" && opts.Username == "" {
+		return nil, errors.New("a password needs a user name")
+	}
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		conn, err = net.DialTimeout("tcp", hostPort(u, "1883"), 10*time.Second)
+	case "ssl", "tls", "mqtts":
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "8883"), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("unknown broker scheme '%s': expected tcp or ssl", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{conn: conn, done: make(chan struct{})}
+	if err := c.connect(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	return u.Host
+}
+
+func (c *Client) connect(opts Options) error {
+	flags := byte(0x02) // Clean session
+	var payload []byte
+	payload = appendString(payload, opts.ClientID)
+	if opts.WillTopic != "" {
+		flags |= 0x04 | 0x20 // Will, retained
+		payload = appendString(payload, opts.WillTopic)
+		payload = appendBytes(payload, opts.WillPayload)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = appendString(payload, opts.Password)
+	}
+
+	keepAlive := int(opts.KeepAlive.Seconds())
+	if keepAlive > 0xFFFF {
+		keepAlive = 0xFFFF
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = append(body, payload...)
+	if err := c.write(packetConnect, body); err != nil {
+		return err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetReadDeadline(time.Time{})
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(c.conn, ack); err != nil {
+		return fmt.Errorf("reading CONNACK: %w", err)
+	}
+	if ack[0] != packetConnAck || ack[1] != 2 {
+		return errors.New("broker didn't answer with a CONNACK")
+	}
+	if ack[3] != 0 {
+		if msg, ok := connectErrors[ack[3]]; ok {
+			return fmt.Errorf("connection refused: %s", msg)
+		}
+		return fmt.Errorf("connection refused with code %d", ack[3])
+	}
+
+	// Nothing else should come back for QoS 0 publishes, but read and drop
+	// anything that does, so the broker never blocks on a full socket.
+	go io.Copy(io.Discard, c.conn)
+	if opts.KeepAlive > 0 {
+		go c.ping(opts.KeepAlive / 2)
+	}
+	return nil
+}
+
+// ping sends a PINGREQ every interval until the client is closed, so the
+// broker knows it's still there between publishes.
+func (c *Client) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			// If the connection's gone, the next publish finds out
+			if err := c.write(packetPingReq, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Publish sends payload to topic at QoS 0, retained by the broker if retain
+// is true.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	header := byte(packetPublish)
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	return c.write(header, append(body, payload...))
+}
+
+// Close disconnects cleanly, so the broker doesn't publish the will.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.write(packetDisconnect, nil)
+	return c.conn.Close()
+}
+
+func (c *Client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	// The remaining length is 7 bits per byte, least significant first
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b, data []byte) []byte {
+	b = append(b, byte(len(data)>>8), byte(len(data)))
+	return append(b, data...)
+}
@@ -0,0 +1,145 @@
+package mqtt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/mqtt/mqtttest"
+)
+
+// next returns the next packet the broker gets, failing if none comes soon.
+func next(t *testing.T, b *mqtttest.Broker) mqtttest.Packet {
+	t.Helper()
+	select {
+	case p := <-b.Packets:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("no packet from the client")
+		return mqtttest.Packet{}
+	}
+}
+
+func TestConnect(t *testing.T) {
+	b := mqtttest.NewBroker(t)
+	c, err := Dial(b.URL, Options{
+		ClientID:    "sundial-test",
+		Username:    "user",
+		Password:    "secret",
+		KeepAlive:   90 * time.Second,
+		WillTopic:   "sundial/test/status",
+		WillPayload: []byte("offline"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	p := next(t, b)
+	if p.Header != packetConnect {
+		t.Fatalf("first packet = %#x, want CONNECT", p.Header)
+	}
+	protocol, rest := mqtttest.String(p.Body)
+	if protocol != "MQTT" || len(rest) < 4 || rest[0] != 4 {
+		t.Fatalf("CONNECT is for %q level %v, want MQTT 3.1.1", protocol, rest)
+	}
+	// User name, password, will retained, will, clean session
+	if flags := rest[1]; flags != 0x80|0x40|0x20|0x04|0x02 {
+		t.Errorf("CONNECT flags = %08b, want 11100110", flags)
+	}
+	if keepAlive := int(rest[2])<<8 | int(rest[3]); keepAlive != 90 {
+		t.Errorf("keep alive = %ds, want 90s", keepAlive)
+	}
+
+	var fields []string
+	for rest = rest[4:]; len(rest) > 0; {
+		var field string
+		field, rest = mqtttest.String(rest)
+		fields = append(fields, field)
+	}
+	want := []string{"sundial-test", "sundial/test/status", "offline", "user", "secret"}
+	if strings.Join(fields, " ") != strings.Join(want, " ") {
+		t.Errorf("CONNECT payload = %q, want %q", fields, want)
+	}
+}
+
+func TestConnectWithoutWillOrLogin(t *testing.T) {
+	b := mqtttest.NewBroker(t)
+	c, err := Dial(b.URL, Options{ClientID: "sundial-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, rest := mqtttest.String(next(t, b).Body)
+	if flags := rest[1]; flags != 0x02 {
+		t.Errorf("CONNECT flags = %08b, want only a clean session", flags)
+	}
+}
+
+func TestPasswordNeedsUsername(t *testing.T) {
+	b := mqtttest.NewBroker(t)
+	if _, err := Dial(b.URL, Options{ClientID: "sundial-test", Password: "secret"}); err == nil {
+		t.Fatal("Dial with a password but no user name succeeded")
+	}
+	select {
+	case p := <-b.Packets:
+		t.Errorf("broker got %#x, want nothing sent", p.Header)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPublish(t *testing.T) {
+	b := mqtttest.NewBroker(t)
+	c, err := Dial(b.URL, Options{ClientID: "sundial-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, b)
+
+	// Long enough to need two bytes of remaining length
+	payload := bytes.Repeat([]byte("x"), 300)
+	if err := c.Publish("sundial/test/percent", payload, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Publish("sundial/test/phase", []byte("day"), false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct {
+		topic   string
+		payload []byte
+		retain  bool
+	}{
+		{"sundial/test/percent", payload, true},
+		{"sundial/test/phase", []byte("day"), false},
+	} {
+		p := next(t, b)
+		topic, got := p.Topic()
+		if p.Type() != 3 || topic != want.topic || !bytes.Equal(got, want.payload) || p.Retain() != want.retain {
+			t.Errorf("got %#x %q %q, want a PUBLISH of %q to %q, retained %v", p.Header, topic, got, want.payload, want.topic, want.retain)
+		}
+	}
+
+	c.Close()
+	if p := next(t, b); p.Header != packetDisconnect {
+		t.Errorf("on Close, got %#x, want DISCONNECT", p.Header)
+	}
+}
+
+func TestPing(t *testing.T) {
+	b := mqtttest.NewBroker(t)
+	c, err := Dial(b.URL, Options{ClientID: "sundial-test", KeepAlive: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	next(t, b)
+
+	// Pinged twice per keep alive, with nothing else to send
+	for i := 0; i < 2; i++ {
+		if p := next(t, b); p.Header != packetPingReq {
+			t.Errorf("got %#x, want PINGREQ", p.Header)
+		}
+	}
+}
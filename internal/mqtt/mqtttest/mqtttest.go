@@ -0,0 +1,112 @@
+// Package mqtttest is a fake MQTT broker for testing clients. It accepts
+// every connection and records the packets clients send.
+package mqtttest
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+)
+
+// A Packet is one control packet a client sent.
+type Packet struct {
+	Header byte // The packet type in the high nibble, and its flags
+	Body   []byte
+}
+
+// Type returns the packet's control packet type, e.g. 1 for CONNECT.
+func (p Packet) Type() byte {
+	return p.Header >> 4
+}
+
+// Retain returns whether a PUBLISH packet asks the broker to retain it.
+func (p Packet) Retain() bool {
+	return p.Header&0x01 != 0
+}
+
+// Topic returns a PUBLISH packet's topic and payload, for QoS 0.
+func (p Packet) Topic() (topic string, payload []byte) {
+	return String(p.Body)
+}
+
+// String reads an MQTT string from the start of b, and returns it and the
+// rest of b.
+func String(b []byte) (string, []byte) {
+	if len(b) < 2 {
+		return "", nil
+	}
+	n := int(b[0])<<8 | int(b[1])
+	if len(b) < 2+n {
+		return "", nil
+	}
+	return string(b[2 : 2+n]), b[2+n:]
+}
+
+// A Broker listens on a local port, answers every CONNECT with a successful
+// CONNACK, and sends every packet it reads to Packets.
+type Broker struct {
+	URL     string
+	Packets chan Packet
+	ln      net.Listener
+}
+
+// NewBroker starts a Broker, and stops it when the test finishes.
+func NewBroker(t testing.TB) *Broker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Broker{URL: "tcp://" + ln.Addr().String(), Packets: make(chan Packet, 100), ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *Broker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *Broker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		// The remaining length is 7 bits per byte, least significant first
+		var length, shift int
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			length |= int(c&0x7F) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		p := Packet{Header: header, Body: body}
+		switch p.Type() {
+		case 1: // CONNECT
+			conn.Write([]byte{0x20, 2, 0, 0})
+		case 12: // PINGREQ
+			conn.Write([]byte{0xD0, 0})
+		}
+		b.Packets <- p
+	}
+}
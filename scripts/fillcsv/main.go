@@ -0,0 +1,92 @@
+// Command fillcsv fills in the time zone and elevation columns of cities.csv
+// without GeoNames, for when makecsv.sh can't download it. It reads rows of
+// at least name, country code, FIPS code, latitude, and longitude on stdin,
+// and writes them with all seven columns trim_csv.py writes.
+//
+// Time zones come from the coordinates, using the timezone-boundary-builder
+// polygons. Elevations come from the public domain tidwall/cities list, for
+// cities with the same name within about 10 km, and are left empty for the
+// rest. makecsv.sh fills in every elevation from GeoNames, so prefer it.
+//
+//	go run . < ../../internal/core/cities.csv > cities.csv
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ringsaturn/tzf"
+	"github.com/tidwall/cities"
+	"golang.org/x/text/unicode/norm"
+)
+
+type point struct {
+	lat, lon, elevation float64
+}
+
+// fold lowercases s and strips its accents, so "Zürich" matches "Zurich".
+func fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(s)) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func main() {
+	finder, err := tzf.NewDefaultFinder()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	byName := map[string][]point{}
+	for _, c := range cities.Cities {
+		byName[fold(c.City)] = append(byName[fold(c.City)], point{c.Latitude, c.Longitude, c.Altitude})
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for in.Scan() {
+		f := strings.Split(in.Text(), "\t")
+		if len(f) < 5 {
+			fmt.Fprintf(os.Stderr, "too few columns: %q\n", in.Text())
+			os.Exit(1)
+		}
+		for len(f) < 7 {
+			f = append(f, "")
+		}
+		lat, err1 := strconv.ParseFloat(f[3], 64)
+		lon, err2 := strconv.ParseFloat(f[4], 64)
+		if err1 != nil || err2 != nil {
+			fmt.Fprintf(os.Stderr, "bad coordinates: %q\n", in.Text())
+			os.Exit(1)
+		}
+
+		if f[5] == "" {
+			f[5] = finder.GetTimezoneName(lon, lat)
+		}
+		if f[6] == "" {
+			// A tenth of a degree of latitude is about 11 km
+			best := 0.1
+			for _, p := range byName[fold(f[0])] {
+				if d := math.Hypot(p.lat-lat, (p.lon-lon)*math.Cos(lat*math.Pi/180)); d < best {
+					best = d
+					f[6] = strconv.Itoa(int(math.Round(p.elevation)))
+				}
+			}
+		}
+		fmt.Fprintln(out, strings.Join(f, "\t"))
+	}
+	if err := in.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
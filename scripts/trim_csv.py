@@ -36,6 +36,9 @@ with cities.open("r") as f:
         name, lat, long = line[1], float(line[4]), float(line[5])
         country = line[8]
         fipscode = line[10]
+        # Prefer the surveyed elevation, falling back to the elevation model
+        elevation = line[15] or line[16]
+        timezone = line[17]
         lat = round(lat, 2)
         long = round(long, 2)
-        print(f"{name}\t{country}\t{fipscode}\t{lat}\t{long}")
+        print(f"{name}\t{country}\t{fipscode}\t{lat}\t{long}\t{timezone}\t{elevation}")
@@ -0,0 +1,22 @@
+// Package sundial finds how far through the day or night it is anywhere in
+// the world, when the sun rises, sets, and crosses other altitudes, and
+// where it is in the sky.
+//
+// Look places up in the built-in dataset of every city with at least 15,000
+// people, or make a Place from coordinates, then ask a Calculator about
+// them. New uses the NOAA solar calculator and the built-in dataset; pass
+// WithEngine(SPA) for the NREL Solar Position Algorithm, or
+// WithDatasetFile for a dataset of your own. A name that matches more than
+// one city returns an *AmbiguousPlaceError listing them. See the examples
+// for each.
+//
+// Nothing in this package prints anything.
+//
+// # Compatibility
+//
+// This package follows semantic versioning: within a major version, its
+// exported API only grows, and existing identifiers keep their meaning.
+// Computed times and angles may change slightly between releases as the
+// calculations improve, and the built-in dataset is updated as cities grow.
+// The sundial command and everything under internal/ make no such promise.
+package sundial
@@ -0,0 +1,95 @@
+package sundial_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/riley-martine/sundial/sundial"
+)
+
+func ExampleCalculator_Period() {
+	calc := sundial.New()
+	denver, err := calc.FindPlace("Denver", "US", "CO")
+	if err != nil {
+		panic(err)
+	}
+	at := time.Date(2024, 6, 21, 12, 0, 0, 0, denver.Location())
+	period, err := calc.Period(denver, at)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%.0f%% %s\n", period.Percent(), period.Phase)
+	fmt.Println("sunset at", period.Sunset.Format("15:04 MST"))
+	// Output:
+	// 43% day
+	// sunset at 20:38 MDT
+}
+
+func ExampleCalculator_Events() {
+	calc := sundial.New()
+	denver, err := calc.FindPlace("Denver", "US", "CO")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range calc.Events(denver, time.Date(2024, 6, 21, 0, 0, 0, 0, denver.Location())) {
+		fmt.Println(e.Name, e.Time.Format("15:04"))
+	}
+	// Output:
+	// astronomical_dawn 03:29
+	// nautical_dawn 04:18
+	// civil_dawn 04:59
+	// morning_golden_hour_start 05:12
+	// sunrise 05:25
+	// morning_golden_hour_end 06:12
+	// evening_golden_hour_start 19:50
+	// sunset 20:38
+	// evening_golden_hour_end 20:51
+	// civil_dusk 21:04
+	// nautical_dusk 21:45
+	// astronomical_dusk 22:33
+}
+
+func ExampleCalculator_FindPlace() {
+	calc := sundial.New()
+	_, err := calc.FindPlace("Springfield", "US", "")
+	var ambiguous *sundial.AmbiguousPlaceError
+	if errors.As(err, &ambiguous) {
+		for _, p := range ambiguous.Candidates {
+			fmt.Println(p.Name, p.CountryCode, p.FipsCode)
+		}
+	}
+	// Output:
+	// Springfield US IL
+	// Springfield US MO
+	// Springfield US OH
+	// Springfield US PA
+	// Springfield US TN
+	// Springfield US VA
+	// Springfield US MA
+	// Springfield US OR
+}
+
+func ExampleCalculator_NextSunriseOrSunset() {
+	calc := sundial.New()
+	denver, err := calc.FindPlace("Denver", "US", "CO")
+	if err != nil {
+		panic(err)
+	}
+	e, ok := calc.NextSunriseOrSunset(denver, time.Date(2024, 6, 21, 22, 0, 0, 0, denver.Location()))
+	if ok {
+		fmt.Println(e.Name, e.Time.Format("Mon 15:04"))
+	}
+	// Output:
+	// sunrise Sat 05:25
+}
+
+func ExampleCalculator_Position() {
+	place := sundial.Place{Name: "Golden", Latitude: 39.742476, Longitude: -105.1786, TimeZone: "America/Denver", Elevation: 1830}
+	// The NREL Solar Position Algorithm, for solar energy work
+	calc := sundial.New(sundial.WithEngine(sundial.SPA))
+	p := calc.Position(place, time.Date(2003, 10, 17, 12, 30, 30, 0, time.FixedZone("MST", -7*3600)))
+	fmt.Printf("elevation %.2f°, azimuth %.2f°\n", p.Elevation, p.Azimuth)
+	// Output:
+	// elevation 39.89°, azimuth 194.34°
+}
@@ -0,0 +1,270 @@
+package sundial
+
+import (
+	"errors"
+	"time"
+
+	"github.com/riley-martine/sundial/internal/core"
+)
+
+// An Engine is the algorithm used to find the sun's position.
+type Engine int
+
+const (
+	// NOAA is the NOAA solar calculator, good to about a minute for
+	// sunrise and sunset from 1800 to 2100. It's the default.
+	NOAA Engine = iota
+	// SPA is the NREL Solar Position Algorithm, good to 0.0003° from -2000
+	// to 6000, and a few times slower.
+	SPA
+)
+
+func (e Engine) String() string {
+	switch e {
+	case NOAA:
+		return "NOAA"
+	case SPA:
+		return "SPA"
+	default:
+		return "unknown engine"
+	}
+}
+
+// A Calculator answers questions about the sun. It's safe for concurrent
+// use. Make one with New.
+type Calculator struct {
+	engine  Engine
+	dataset core.Dataset
+}
+
+// An Option configures a Calculator.
+type Option func(*Calculator)
+
+// WithEngine sets the algorithm used to find the sun's position.
+func WithEngine(e Engine) Option {
+	return func(c *Calculator) {
+		c.engine = e
+	}
+}
+
+// WithDatasetFile looks places up in the file at path instead of the
+// built-in dataset. It has one city per line, with tab-separated name,
+// country code, FIPS code, latitude, longitude, and optionally IANA time zone
+// and elevation in meters. The file is read on each lookup.
+func WithDatasetFile(path string) Option {
+	return func(c *Calculator) {
+		c.dataset = core.DatasetFile(path)
+	}
+}
+
+// New returns a Calculator using the NOAA engine and the built-in dataset,
+// unless opts say otherwise.
+func New(opts ...Option) *Calculator {
+	c := &Calculator{engine: NOAA, dataset: core.EmbeddedDataset}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// A Place is somewhere on Earth.
+type Place struct {
+	Name        string
+	CountryCode string // ISO 3166-1 alpha-2, e.g. "US"
+	// The region, e.g. "CO". In the US, the two-letter state abbreviation;
+	// elsewhere, the GeoNames admin1 code.
+	FipsCode  string
+	Latitude  float64 // Degrees north
+	Longitude float64 // Degrees east
+	// IANA name, e.g. "America/Denver", or "Local" for time.Local. If empty,
+	// or unknown to the system's zone database, times are in time.Local, and
+	// a warning is logged.
+	TimeZone  string
+	Elevation float64 // Meters above sea level, for lowering the horizon
+}
+
+// Location returns the place's time zone.
+func (p Place) Location() *time.Location {
+	return p.city(NOAA).Location()
+}
+
+func (p Place) city(e Engine) *core.CityInfo {
+	city := &core.CityInfo{
+		Name:        p.Name,
+		CountryCode: p.CountryCode,
+		FipsCode:    p.FipsCode,
+		Latitude:    p.Latitude,
+		Longitude:   p.Longitude,
+		TimeZone:    p.TimeZone,
+		Elevation:   p.Elevation,
+		Horizon:     core.Horizon{Dip: true},
+	}
+	if e == SPA {
+		city.Precision = core.HighPrecision
+	}
+	return city
+}
+
+func placeOf(city *core.CityInfo) Place {
+	return Place{
+		Name:        city.Name,
+		CountryCode: city.CountryCode,
+		FipsCode:    city.FipsCode,
+		Latitude:    city.Latitude,
+		Longitude:   city.Longitude,
+		TimeZone:    city.TimeZone,
+		Elevation:   city.Elevation,
+	}
+}
+
+// An AmbiguousPlaceError is returned by FindPlace when more than one place
+// matches. Narrow it down with a country code and FIPS code.
+type AmbiguousPlaceError struct {
+	Candidates []Place
+}
+
+func (e *AmbiguousPlaceError) Error() string {
+	return "more than one place matches"
+}
+
+// FindPlace finds the one city with the given name, and the country code and
+// FIPS code if they aren't empty.
+func (c *Calculator) FindPlace(name, countryCode, fipsCode string) (Place, error) {
+	city, err := c.dataset.FindCity(name, countryCode, fipsCode)
+	var narrowingError *core.NarrowingError
+	if errors.As(err, &narrowingError) {
+		e := &AmbiguousPlaceError{}
+		for _, city := range narrowingError.Cities {
+			e.Candidates = append(e.Candidates, placeOf(city))
+		}
+		return Place{}, e
+	} else if err != nil {
+		return Place{}, err
+	}
+	return placeOf(city), nil
+}
+
+// SearchPlaces returns the cities whose names start with prefix.
+func (c *Calculator) SearchPlaces(prefix string) ([]Place, error) {
+	cities, err := c.dataset.FindCities(prefix, "", "", true)
+	if err != nil {
+		return nil, err
+	}
+	places := make([]Place, len(cities))
+	for i, city := range cities {
+		places[i] = placeOf(city)
+	}
+	return places, nil
+}
+
+// A Period is the day or night a moment falls in. Days run from sunrise to
+// sunset, and nights from sunset to sunrise.
+type Period struct {
+	Place Place
+	At    time.Time
+	Day   bool // Between sunrise and sunset
+	// "day", "night", "golden hour" (the sun from -4° to 6°), or "blue hour"
+	// (from -6° to -4°).
+	Phase    string
+	Start    time.Time
+	Duration time.Duration
+	// Of the calendar day of At, in the place's time zone.
+	Sunrise time.Time
+	Sunset  time.Time
+}
+
+// Fraction returns how far through the period At is, from 0 to 1.
+func (p Period) Fraction() float64 {
+	return p.At.Sub(p.Start).Seconds() / p.Duration.Seconds()
+}
+
+// Percent returns how far through the period At is, from 0 to 100.
+func (p Period) Percent() float64 {
+	return p.Fraction() * 100
+}
+
+// End returns when the period ends.
+func (p Period) End() time.Time {
+	return p.Start.Add(p.Duration)
+}
+
+// Period returns the day or night at falls in at place. It returns an error
+// if the sun doesn't rise and set there that day.
+func (c *Calculator) Period(place Place, at time.Time) (Period, error) {
+	city := place.city(c.engine)
+	at = at.In(city.Location())
+	p, err := core.GetPeriod(city, at)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{
+		Place:    place,
+		At:       at,
+		Day:      p.Day,
+		Phase:    p.Phase(),
+		Start:    p.Start,
+		Duration: p.Duration,
+		Sunrise:  p.Sunrise,
+		Sunset:   p.Sunset,
+	}, nil
+}
+
+// An Event is a moment the center of the sun crosses an altitude.
+type Event struct {
+	// One of "astronomical_dawn", "nautical_dawn", "civil_dawn",
+	// "morning_golden_hour_start", "sunrise", "morning_golden_hour_end",
+	// "evening_golden_hour_start", "sunset", "evening_golden_hour_end",
+	// "civil_dusk", "nautical_dusk", or "astronomical_dusk".
+	Name     string
+	Time     time.Time
+	Altitude float64 // Degrees above the horizon, lowered for sunrise and sunset by the place's elevation
+	Rising   bool
+}
+
+// Events returns the events on the calendar day of day in place's time zone,
+// in the order they usually happen. Events that don't happen that day, like
+// dusk in a polar summer, are left out.
+func (c *Calculator) Events(place Place, day time.Time) []Event {
+	city := place.city(c.engine)
+	day = day.In(city.Location())
+	var events []Event
+	for _, e := range city.AllEvents() {
+		if t, ok := city.EventTime(e, day); ok {
+			events = append(events, Event{Name: e.Name, Time: t, Altitude: city.EventAltitude(e, day), Rising: e.Rising})
+		}
+	}
+	return events
+}
+
+// NextSunriseOrSunset returns the first sunrise or sunset after at, if there
+// is one within a year.
+func (c *Calculator) NextSunriseOrSunset(place Place, at time.Time) (Event, bool) {
+	city := place.city(c.engine)
+	at = at.In(city.Location())
+	e, t, ok := city.NextEvent(at, core.Sunrise, core.Sunset)
+	if !ok {
+		return Event{}, false
+	}
+	return Event{Name: e.Name, Time: t, Altitude: city.EventAltitude(e, t), Rising: e.Rising}, true
+}
+
+// SolarNoon returns when the sun crosses the meridian on the calendar day of
+// day in place's time zone.
+func (c *Calculator) SolarNoon(place Place, day time.Time) time.Time {
+	city := place.city(c.engine)
+	return city.SolarNoon(day.In(city.Location()))
+}
+
+// A Position is where the sun is in the sky.
+type Position struct {
+	Elevation   float64 // Degrees above the horizon, corrected for refraction
+	Azimuth     float64 // Degrees clockwise from north
+	HourAngle   float64 // Degrees west of the meridian; negative before solar noon
+	Declination float64 // Degrees north of the celestial equator
+}
+
+// Position returns where the sun is in place's sky at at.
+func (c *Calculator) Position(place Place, at time.Time) Position {
+	p := place.city(c.engine).SolarPosition(at)
+	return Position{Elevation: p.Elevation, Azimuth: p.Azimuth, HourAngle: p.HourAngle, Declination: p.Declination}
+}